@@ -212,7 +212,7 @@ func connect_to_bootstrap_peers(nodeID C.int, bootstrapPeersJson *C.char) *C.cha
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
-	if err := config.ConnectToBootstrapPeers(ctx, host, bootstrapPeers); err != nil {
+	if err := config.ConnectToBootstrapPeers(ctx, host, bootstrapPeers, nil); err != nil {
 		return C.CString(fmt.Sprintf(`{"error": "failed to connect to bootstrap peers: %v"}`, err))
 	}
 	
@@ -28,6 +28,9 @@ func create_node(addressC *C.char, nodeTypeC *C.char, nameC *C.char, description
 	cfg.Address = core.NewOAddress(address)
 	cfg.Name = name
 	cfg.Description = description
+	// Keep Go log output off the host process's stdout
+	cfg.Logger = core.NewNoOpLogger()
+	cfg.Network.Logger = cfg.Logger
 	
 	switch nodeType {
 	case "leader":
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+	"github.com/olane-labs/olane-go/pkg/core"
+)
+
+// LeaderEnv names the environment variable holding the default leader multiaddr
+const LeaderEnv = "OLANE_LEADER"
+
+// clientFlags holds the flags shared by commands that talk to a network
+type clientFlags struct {
+	leader      string
+	networkName string
+	mdns        bool
+	timeout     time.Duration
+	json        bool
+	verbose     bool
+}
+
+// register adds the shared client flags to a flag set
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.leader, "leader", os.Getenv(LeaderEnv), "leader multiaddr including /p2p/, also read from $"+LeaderEnv)
+	fs.StringVar(&f.networkName, "network", "", "network name used to scope mDNS discovery")
+	fs.BoolVar(&f.mdns, "mdns", false, "discover peers on the local network")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "overall command timeout")
+	fs.BoolVar(&f.json, "json", false, "print JSON instead of tables")
+	fs.BoolVar(&f.verbose, "verbose", false, "print node logs")
+}
+
+// client is a short-lived node used to issue requests to a network
+type client struct {
+	node   *core.CoreNode
+	leader multiaddr.Multiaddr
+}
+
+// newClient creates and initializes a client node. The node never registers
+// with the leader; requests reach the network through the leader's transports.
+func newClient(ctx context.Context, f *clientFlags) (*client, error) {
+	c := &client{}
+
+	network := config.DefaultLibp2pConfig()
+	network.EnableMDNS = f.mdns
+	if f.leader != "" {
+		leader, err := leaderAddress(f.leader)
+		if err != nil {
+			return nil, err
+		}
+		c.leader = leader.LibP2PTransports()[0]
+		network.BootstrapPeers = []string{f.leader}
+		network.Bootstrap = &config.BootstrapConfig{MaxAttempts: 1, MinPeers: 1}
+	}
+
+	cfg := core.DefaultCoreConfig()
+	cfg.Address = core.NewOAddress("o://cli")
+	cfg.Type = core.NodeTypeHuman
+	cfg.Name = "cli"
+	cfg.NetworkName = f.networkName
+	cfg.Network = network
+	if f.verbose {
+		cfg.Logger = core.NewLogger("olane")
+	} else {
+		cfg.Logger = core.NewNoOpLogger()
+	}
+
+	c.node = core.NewCoreNode(cfg)
+	if err := c.node.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// close stops the client node
+func (c *client) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = c.node.Stop(ctx)
+}
+
+// target parses an o-address and routes it through the leader unless it
+// already carries transports. The leader forwards requests for other
+// addresses to the registered node that serves them.
+func (c *client) target(value string) (*core.OAddress, error) {
+	address, err := core.ParseOAddress(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(address.AllTransports()) == 0 && c.leader != nil {
+		address.SetTransports([]multiaddr.Multiaddr{c.leader})
+	}
+	return address, nil
+}
+
+// use calls a method and converts error responses to errors
+func (c *client) use(ctx context.Context, address *core.OAddress, method string, params map[string]interface{}) (*core.OResponse, error) {
+	response, err := c.node.Use(ctx, address, method, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return response, response.Error
+	}
+	return response, nil
+}
+
+// session is a client opened for a single command
+type session struct {
+	*client
+	flags clientFlags
+	args  []string
+	ctx   context.Context
+	stop  context.CancelFunc
+}
+
+// openSession parses the shared client flags plus any extra flags, checks for
+// nargs positional arguments and starts a client bounded by --timeout
+func openSession(name string, args []string, nargs int, extra func(fs *flag.FlagSet)) (*session, error) {
+	s := &session{}
+	fs := newFlagSet(name)
+	s.flags.register(fs)
+	if extra != nil {
+		extra(fs)
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectArgs(fs, positional, nargs); err != nil {
+		return nil, err
+	}
+	s.args = positional
+
+	s.ctx, s.stop = context.WithTimeout(context.Background(), s.flags.timeout)
+	s.client, err = newClient(s.ctx, &s.flags)
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// close stops the client and releases the command context
+func (s *session) close() {
+	s.client.close()
+	s.stop()
+}
+
+// runWhoAmI prints the description of the node at an address
+func runWhoAmI(args []string) error {
+	s, err := openSession("whoami", args, 1, nil)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	address, err := s.target(s.args[0])
+	if err != nil {
+		return err
+	}
+	response, err := s.use(s.ctx, address, "whoami", nil)
+	if err != nil {
+		return err
+	}
+
+	var whoami core.WhoAmIResponse
+	if err := decodeResult(response.Result, &whoami); err != nil {
+		return err
+	}
+	if s.flags.json {
+		return printJSON(whoami)
+	}
+
+	t := newTable()
+	t.row("Address:", whoami.Address)
+	t.row("Type:", whoami.Type)
+	t.row("Description:", whoami.Description)
+	t.row("Peer ID:", whoami.PeerID)
+	t.row("Requests:", fmt.Sprintf("%d ok, %d failed", whoami.SuccessCount, whoami.ErrorCount))
+	for i, transport := range whoami.Transports {
+		label := ""
+		if i == 0 {
+			label = "Transports:"
+		}
+		t.row(label, transport)
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+
+	if len(whoami.Methods) == 0 {
+		return nil
+	}
+	fmt.Println()
+
+	names := make([]string, 0, len(whoami.Methods))
+	for name := range whoami.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t = newTable("METHOD", "DESCRIPTION")
+	for _, name := range names {
+		t.row(name, whoami.Methods[name].Description)
+	}
+	return t.flush()
+}
+
+// runUse calls a method on an address and prints its result
+func runUse(args []string) error {
+	var rawParams string
+	s, err := openSession("use", args, 2, func(fs *flag.FlagSet) {
+		fs.StringVar(&rawParams, "params", "", "method parameters as a JSON object")
+	})
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	params, err := parseParams(rawParams)
+	if err != nil {
+		return err
+	}
+	address, err := s.target(s.args[0])
+	if err != nil {
+		return err
+	}
+
+	response, err := s.use(s.ctx, address, s.args[1], params)
+	if s.flags.json && response != nil {
+		if printErr := printJSON(response); printErr != nil {
+			return printErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return printValue(os.Stdout, response.Result)
+}
+
+// runResolve prints the next hop and target an address translates to
+func runResolve(args []string) error {
+	s, err := openSession("resolve", args, 1, nil)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	address, err := s.target(s.args[0])
+	if err != nil {
+		return err
+	}
+	result, err := s.node.TranslateAddress(s.ctx, address)
+	if err != nil {
+		return err
+	}
+
+	resolved := struct {
+		NextHop           string   `json:"nextHop"`
+		NextHopTransports []string `json:"nextHopTransports"`
+		Target            string   `json:"target"`
+	}{
+		NextHop:           result.NextHopAddress.String(),
+		NextHopTransports: result.NextHopAddress.AllTransports(),
+		Target:            result.TargetAddress.String(),
+	}
+	if s.flags.json {
+		return printJSON(resolved)
+	}
+
+	t := newTable()
+	t.row("Target:", resolved.Target)
+	t.row("Next hop:", resolved.NextHop)
+	for i, transport := range resolved.NextHopTransports {
+		label := ""
+		if i == 0 {
+			label = "Via:"
+		}
+		t.row(label, transport)
+	}
+	return t.flush()
+}
+
+// peerInfo describes a connected peer
+type peerInfo struct {
+	ID        string   `json:"id"`
+	Addresses []string `json:"addresses"`
+	Leader    bool     `json:"leader"`
+	Latency   string   `json:"latency,omitempty"`
+}
+
+// runPeers lists the peers the client node is connected to after discovery
+func runPeers(args []string) error {
+	var wait time.Duration
+	s, err := openSession("peers", args, 0, func(fs *flag.FlagSet) {
+		fs.DurationVar(&wait, "wait", 2*time.Second, "time to wait for discovery before listing peers")
+	})
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	select {
+	case <-s.ctx.Done():
+	case <-time.After(wait):
+	}
+
+	h := s.node.Host()
+	ids := h.Network().Peers()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	peers := make([]peerInfo, 0, len(ids))
+	for _, id := range ids {
+		info := peerInfo{ID: id.String(), Addresses: []string{}}
+		for _, conn := range h.Network().ConnsToPeer(id) {
+			info.Addresses = append(info.Addresses, conn.RemoteMultiaddr().String())
+		}
+		if protocols, err := h.Peerstore().SupportsProtocols(id, core.LeaderProtocol); err == nil && len(protocols) > 0 {
+			info.Leader = true
+		}
+		if latency := h.Peerstore().LatencyEWMA(id); latency > 0 {
+			info.Latency = latency.Round(time.Microsecond).String()
+		}
+		peers = append(peers, info)
+	}
+
+	if s.flags.json {
+		return printJSON(peers)
+	}
+
+	t := newTable("PEER ID", "LEADER", "LATENCY", "ADDRESSES")
+	for _, p := range peers {
+		latency := p.Latency
+		if latency == "" {
+			latency = "-"
+		}
+		t.row(p.ID, p.Leader, latency, strings.Join(p.Addresses, ","))
+	}
+	return t.flush()
+}
+
+// parseParams decodes the --params JSON object
+func parseParams(raw string) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if strings.TrimSpace(raw) == "" {
+		return params, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &params); err != nil {
+		return nil, fmt.Errorf("params must be a JSON object: %w", err)
+	}
+	return params, nil
+}
+
+// decodeResult converts a generic response result into a typed value
+func decodeResult(result interface{}, v interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unexpected result: %w", err)
+	}
+	return nil
+}
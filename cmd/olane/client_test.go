@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+	"github.com/olane-labs/olane-go/pkg/core"
+)
+
+// startNode starts a loopback node, registering it with leader if one is given
+func startNode(t *testing.T, ctx context.Context, address string, nodeType core.NodeType, leader string) *core.CoreNode {
+	network := config.DefaultLibp2pConfig()
+	network.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+	network.EnableRelay = false
+	network.EnableDHT = false
+
+	cfg := core.DefaultCoreConfig()
+	cfg.Address = core.NewOAddress(address)
+	cfg.Type = nodeType
+	cfg.Network = network
+	cfg.Logger = core.NewNoOpLogger()
+	if leader != "" {
+		leaderAddr, err := leaderAddress(leader)
+		if err != nil {
+			t.Fatalf("Invalid leader: %v", err)
+		}
+		cfg.Leader = leaderAddr
+	}
+
+	node := core.NewCoreNode(cfg)
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Failed to start %s: %v", address, err)
+	}
+	t.Cleanup(func() { node.Stop(context.Background()) })
+	return node
+}
+
+// captureStdout returns what run prints to stdout
+func captureStdout(t *testing.T, run func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+	runErr := run()
+	w.Close()
+	return string(<-output), runErr
+}
+
+func TestClientCommands(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := startNode(t, ctx, "o://leader", core.NodeTypeLeader, "")
+	leaderAddr := leader.Transports()[0] + "/p2p/" + leader.ID().String()
+
+	tool := startNode(t, ctx, "o://tools/calc", core.NodeTypeTool, leaderAddr)
+	tool.Handle("add", func(ctx context.Context, req *core.ORequest) (interface{}, error) {
+		a, _ := req.Params["a"].(float64)
+		b, _ := req.Params["b"].(float64)
+		return a + b, nil
+	})
+
+	output, err := captureStdout(t, func() error {
+		return runWhoAmI([]string{"o://tools/calc", "--leader", leaderAddr, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("whoami failed: %v", err)
+	}
+	var whoami core.WhoAmIResponse
+	if err := json.Unmarshal([]byte(output), &whoami); err != nil {
+		t.Fatalf("Failed to decode whoami output %q: %v", output, err)
+	}
+	if whoami.Address != "o://tools/calc" || whoami.PeerID != tool.ID().String() {
+		t.Errorf("Expected the tool to answer whoami, got %+v", whoami)
+	}
+	if _, ok := whoami.Methods["add"]; !ok {
+		t.Errorf("Expected the tool's methods, got %v", whoami.Methods)
+	}
+
+	output, err = captureStdout(t, func() error {
+		return runUse([]string{"o://tools/calc", "add", "--params", `{"a": 1, "b": 2}`, "--leader", leaderAddr})
+	})
+	if err != nil {
+		t.Fatalf("use failed: %v", err)
+	}
+	if output != "3\n" {
+		t.Errorf("Expected 3, got %q", output)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// completionTTL is how long fetched addresses and methods are reused for completion
+const completionTTL = 30 * time.Second
+
+// cachedList is a list of completion candidates with its fetch time
+type cachedList struct {
+	values  []string
+	fetched time.Time
+}
+
+// completer implements readline.AutoCompleter for the REPL.
+// The first word completes to REPL commands, registry addresses and, once an
+// address is selected with 'cd', its methods; the word after an address
+// completes to that address's whoami methods.
+type completer struct {
+	commands  []string
+	addresses func() ([]string, error)
+	methods   func(address string) ([]string, error)
+	current   func() string
+
+	cache map[string]cachedList
+	mu    sync.Mutex
+}
+
+// newCompleter creates a completer backed by the given lookups
+func newCompleter(commands []string, addresses func() ([]string, error), methods func(string) ([]string, error), current func() string) *completer {
+	return &completer{
+		commands:  commands,
+		addresses: addresses,
+		methods:   methods,
+		current:   current,
+		cache:     make(map[string]cachedList),
+	}
+}
+
+// cached returns the cached list for key, fetching it when missing or stale.
+// Fetch failures are not cached so the next TAB retries.
+func (c *completer) cached(key string, fetch func() ([]string, error)) []string {
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetched) < completionTTL {
+		return entry.values
+	}
+
+	values, err := fetch()
+	if err != nil {
+		return nil
+	}
+	sort.Strings(values)
+
+	c.mu.Lock()
+	c.cache[key] = cachedList{values: values, fetched: time.Now()}
+	c.mu.Unlock()
+	return values
+}
+
+// invalidate drops all cached candidates
+func (c *completer) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = make(map[string]cachedList)
+}
+
+// addressCandidates returns the known registry addresses
+func (c *completer) addressCandidates() []string {
+	return c.cached("", c.addresses)
+}
+
+// methodCandidates returns the methods of an address
+func (c *completer) methodCandidates(address string) []string {
+	return c.cached(address, func() ([]string, error) {
+		return c.methods(address)
+	})
+}
+
+// candidates returns the completion candidates for word number index given the preceding words
+func (c *completer) candidates(words []string, index int) []string {
+	switch index {
+	case 0:
+		result := append([]string{}, c.commands...)
+		result = append(result, c.addressCandidates()...)
+		if current := c.current(); current != "" {
+			result = append(result, c.methodCandidates(current)...)
+		}
+		return result
+	case 1:
+		switch first := words[0]; {
+		case strings.HasPrefix(first, "o://"):
+			return c.methodCandidates(first)
+		case first == "cd" || first == "whoami" || first == "resolve":
+			return c.addressCandidates()
+		}
+	}
+	return nil
+}
+
+// Do implements readline.AutoCompleter
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	words := strings.Fields(text)
+
+	prefix := ""
+	index := len(words)
+	if len(words) > 0 && !strings.HasSuffix(text, " ") {
+		prefix = words[len(words)-1]
+		index--
+		words = words[:index]
+	}
+
+	var suffixes [][]rune
+	seen := make(map[string]bool)
+	for _, candidate := range c.candidates(words, index) {
+		if seen[candidate] || !strings.HasPrefix(candidate, prefix) {
+			continue
+		}
+		seen[candidate] = true
+		suffixes = append(suffixes, []rune(candidate[len(prefix):]+" "))
+	}
+	return suffixes, len([]rune(prefix))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+	"github.com/olane-labs/olane-go/pkg/core"
+	"github.com/olane-labs/olane-go/pkg/utils"
+)
+
+// FileConfig is the JSON configuration file accepted by 'olane start'
+type FileConfig struct {
+	Address        string                   `json:"address"`
+	Type           string                   `json:"type"`
+	Name           string                   `json:"name"`
+	Description    string                   `json:"description"`
+	NetworkName    string                   `json:"networkName"`
+	Leader         string                   `json:"leader"`
+	Listeners      []string                 `json:"listeners"`
+	BootstrapPeers []string                 `json:"bootstrapPeers"`
+	Identity       string                   `json:"identity"`
+	IdentityFile   string                   `json:"identityFile"`
+	Seed           string                   `json:"seed"`
+	EnableDHT      *bool                    `json:"enableDHT"`
+	EnablePubsub   *bool                    `json:"enablePubsub"`
+	EnableRelay    *bool                    `json:"enableRelay"`
+	EnableMDNS     bool                     `json:"enableMDNS"`
+	Metrics        bool                     `json:"metrics"`
+	MetricsAddress string                   `json:"metricsAddress"`
+	Methods        map[string]*core.OMethod `json:"methods"`
+}
+
+// LoadConfig reads a FileConfig from a JSON file
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg FileConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// CoreConfig converts the file configuration to a node configuration
+func (c *FileConfig) CoreConfig() (*core.CoreConfig, error) {
+	cfg := core.DefaultCoreConfig()
+
+	if c.Address != "" {
+		address, err := core.ParseOAddress(c.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address: %w", err)
+		}
+		cfg.Address = address
+	}
+	if c.Type != "" {
+		cfg.Type = core.NodeType(c.Type)
+	}
+	cfg.Name = c.Name
+	cfg.Description = c.Description
+	cfg.NetworkName = c.NetworkName
+	cfg.Metrics = c.Metrics
+	if c.MetricsAddress != "" {
+		cfg.MetricsAddress = c.MetricsAddress
+	}
+	if c.Methods != nil {
+		cfg.Methods = c.Methods
+	}
+
+	if c.Leader != "" {
+		leader, err := leaderAddress(c.Leader)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Leader = leader
+	}
+
+	network := config.DefaultLibp2pConfig()
+	if len(c.Listeners) > 0 {
+		if err := utils.ValidateMultiaddrs(c.Listeners); err != nil {
+			return nil, err
+		}
+		network.Listeners = c.Listeners
+	}
+	network.BootstrapPeers = c.BootstrapPeers
+	if c.Identity != "" && c.IdentityFile != "" {
+		return nil, fmt.Errorf("identity and identityFile are mutually exclusive")
+	}
+	if c.Identity != "" {
+		identity, err := utils.PrivKeyFromBase64(c.Identity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity: %w", err)
+		}
+		network.Identity = identity
+	}
+	if c.IdentityFile != "" {
+		identity, err := utils.ReadPrivKeyFile(c.IdentityFile)
+		if err != nil {
+			return nil, err
+		}
+		network.Identity = identity
+	}
+	cfg.Seed = c.Seed
+	if c.EnableDHT != nil {
+		network.EnableDHT = *c.EnableDHT
+	}
+	if c.EnablePubsub != nil {
+		network.EnablePubsub = *c.EnablePubsub
+	}
+	if c.EnableRelay != nil {
+		network.EnableRelay = *c.EnableRelay
+	}
+	network.EnableMDNS = c.EnableMDNS
+	cfg.Network = network
+
+	return cfg, nil
+}
+
+// leaderAddress builds the o://leader address reachable at a multiaddr
+func leaderAddress(addr string) (*core.OAddress, error) {
+	ma, err := utils.ParseMultiaddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid leader: %w", err)
+	}
+	if _, err := ma.ValueForProtocol(multiaddr.P_P2P); err != nil {
+		return nil, fmt.Errorf("leader address %s must include a /p2p/ peer ID", addr)
+	}
+
+	leader := core.NewOAddress("o://leader")
+	leader.SetTransports([]multiaddr.Multiaddr{ma})
+	return leader, nil
+}
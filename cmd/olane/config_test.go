@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olane-labs/olane-go/pkg/core"
+)
+
+func TestParseArgsInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("use", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	params := fs.String("params", "", "")
+	asJSON := fs.Bool("json", false, "")
+
+	args, err := parseArgs(fs, []string{"o://tools/calc", "--json", "add", "--params", `{"a":1}`})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+
+	if len(args) != 2 || args[0] != "o://tools/calc" || args[1] != "add" {
+		t.Errorf("Expected positional args [o://tools/calc add], got %v", args)
+	}
+	if *params != `{"a":1}` {
+		t.Errorf("Expected params flag to be parsed, got %q", *params)
+	}
+	if !*asJSON {
+		t.Error("Expected json flag to be set")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.json")
+	data := `{
+		"address": "o://tools/calc",
+		"type": "tool",
+		"networkName": "test-net",
+		"leader": "/ip4/127.0.0.1/tcp/4001/p2p/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ",
+		"listeners": ["/ip4/127.0.0.1/tcp/0"],
+		"enableDHT": false
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	fileConfig, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg, err := fileConfig.CoreConfig()
+	if err != nil {
+		t.Fatalf("Failed to convert config: %v", err)
+	}
+
+	if cfg.Address.String() != "o://tools/calc" {
+		t.Errorf("Expected address o://tools/calc, got %s", cfg.Address)
+	}
+	if cfg.Type != core.NodeTypeTool {
+		t.Errorf("Expected type tool, got %s", cfg.Type)
+	}
+	if cfg.Leader == nil || len(cfg.Leader.LibP2PTransports()) != 1 {
+		t.Error("Expected leader with one transport")
+	}
+	if cfg.Network.EnableDHT {
+		t.Error("Expected DHT to be disabled")
+	}
+	if !cfg.Network.EnablePubsub {
+		t.Error("Expected pubsub to keep its default")
+	}
+}
+
+func TestLoadConfigRejectsLeaderWithoutPeerID(t *testing.T) {
+	fileConfig := &FileConfig{Leader: "/ip4/127.0.0.1/tcp/4001"}
+	if _, err := fileConfig.CoreConfig(); err == nil {
+		t.Error("Expected error for leader without peer ID")
+	}
+}
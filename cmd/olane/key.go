@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/olane-labs/olane-go/pkg/utils"
+)
+
+// keyCommands lists the 'olane key' subcommands
+var keyCommands = map[string]func(args []string) error{
+	"gen":     runKeyGen,
+	"inspect": runKeyInspect,
+	"convert": runKeyConvert,
+	"derive":  runKeyDerive,
+}
+
+// runKey dispatches to a key subcommand
+func runKey(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: olane %s\n", commands["key"].usage)
+		return errUsage
+	}
+	run, ok := keyCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown key command %q\nUsage: olane %s\n", args[0], commands["key"].usage)
+		return errUsage
+	}
+	return run(args[1:])
+}
+
+// keyOutputFlags holds the flags for commands that write a key
+type keyOutputFlags struct {
+	format string
+	out    string
+}
+
+// register adds the key output flags to a flag set
+func (f *keyOutputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", string(utils.KeyFormatBase64), "output format: base64, protobuf or pem")
+	fs.StringVar(&f.out, "out", "", "write the key to this file instead of stdout")
+}
+
+// write encodes the key and writes it to the output file or stdout
+func (f *keyOutputFlags) write(priv crypto.PrivKey) error {
+	data, err := utils.EncodePrivKey(priv, utils.KeyFormat(f.format))
+	if err != nil {
+		return err
+	}
+
+	if f.out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(f.out, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+
+	info, err := utils.InspectPrivKey(priv)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s key for %s to %s\n", info.Type, info.PeerID, f.out)
+	return nil
+}
+
+// runKeyGen generates a new private key
+func runKeyGen(args []string) error {
+	fs := newFlagSet("key")
+	keyType := fs.String("type", "ed25519", "key type: ed25519, rsa, secp256k1 or ecdsa")
+	var output keyOutputFlags
+	output.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, positional, 0); err != nil {
+		return err
+	}
+
+	kt, err := utils.ParseKeyType(*keyType)
+	if err != nil {
+		return err
+	}
+	priv, _, err := utils.GenerateKeyPairOfType(kt)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	return output.write(priv)
+}
+
+// runKeyInspect prints the type, peer ID and public key of a key file
+func runKeyInspect(args []string) error {
+	fs := newFlagSet("key")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, positional, 1); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+	priv, format, err := utils.DecodePrivKey(data)
+	if err != nil {
+		return err
+	}
+	info, err := utils.InspectPrivKey(priv)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(struct {
+			*utils.KeyInfo
+			Format utils.KeyFormat `json:"format"`
+		}{info, format})
+	}
+
+	t := newTable()
+	t.row("Type:", info.Type)
+	if info.Bits > 0 {
+		t.row("Bits:", info.Bits)
+	}
+	t.row("Format:", format)
+	t.row("Peer ID:", info.PeerID)
+	t.row("Public key:", info.PublicKey)
+	return t.flush()
+}
+
+// runKeyConvert re-encodes a key file in another format
+func runKeyConvert(args []string) error {
+	fs := newFlagSet("key")
+	var output keyOutputFlags
+	output.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, positional, 1); err != nil {
+		return err
+	}
+
+	priv, err := utils.ReadPrivKeyFile(positional[0])
+	if err != nil {
+		return err
+	}
+	return output.write(priv)
+}
+
+// runKeyDerive derives a deterministic Ed25519 key from a seed
+func runKeyDerive(args []string) error {
+	fs := newFlagSet("key")
+	seed := fs.String("seed", "", "seed string; the same seed always yields the same key")
+	var output keyOutputFlags
+	output.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, positional, 0); err != nil {
+		return err
+	}
+	if *seed == "" {
+		fmt.Fprintln(fs.Output(), "--seed is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	priv, err := utils.DeriveEd25519Key(*seed)
+	if err != nil {
+		return err
+	}
+	return output.write(priv)
+}
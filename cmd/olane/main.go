@@ -0,0 +1,155 @@
+// Package main implements the olane command line tool for running nodes and
+// calling services on an Olane network.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is a single olane subcommand
+type command struct {
+	usage string
+	short string
+	run   func(args []string) error
+}
+
+// commands lists the available subcommands by name.
+// It is filled in init because the commands refer back to it for their usage.
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"start": {
+			usage: "start --config <file>",
+			short: "Start a node from a JSON configuration file",
+			run:   runStart,
+		},
+		"whoami": {
+			usage: "whoami <o-address> [--leader <multiaddr>] [--json]",
+			short: "Describe the node at an address",
+			run:   runWhoAmI,
+		},
+		"use": {
+			usage: "use <o-address> <method> [--params '{json}'] [--leader <multiaddr>] [--json]",
+			short: "Call a method on an address",
+			run:   runUse,
+		},
+		"repl": {
+			usage: "repl [--leader <multiaddr>] [--history <file>] [--json]",
+			short: "Interactive shell with completion of addresses and methods",
+			run:   runREPL,
+		},
+		"resolve": {
+			usage: "resolve <o-address> [--leader <multiaddr>] [--json]",
+			short: "Show the next hop and target an address translates to",
+			run:   runResolve,
+		},
+		"key": {
+			usage: "key gen [--type ed25519|rsa|secp256k1] [--format base64|protobuf|pem] [--out <file>]\n" +
+				"       olane key inspect <file> [--json]\n" +
+				"       olane key convert <file> --format base64|protobuf|pem [--out <file>]\n" +
+				"       olane key derive --seed <seed> [--format base64|protobuf|pem] [--out <file>]",
+			short: "Generate, inspect, convert and derive node identity keys",
+			run:   runKey,
+		},
+		"peers": {
+			usage: "peers [--leader <multiaddr>] [--wait <duration>] [--json]",
+			short: "List the peers reachable from a short-lived client node",
+			run:   runPeers,
+		},
+	}
+}
+
+// errUsage signals that the arguments were invalid and usage was printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "olane: unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "olane %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// printUsage prints the list of commands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: olane <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", name, commands[name].short)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'olane <command> -h' for command flags.")
+}
+
+// newFlagSet creates the flag set for a subcommand with its usage line
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: olane %s\n\nFlags:\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before, between or after positional
+// arguments and returns the positional arguments in order
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// expectArgs checks the number of positional arguments
+func expectArgs(fs *flag.FlagSet, args []string, n int) error {
+	if len(args) != n {
+		fmt.Fprintf(fs.Output(), "expected %d argument(s), got %d\n", n, len(args))
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// jsonIndent formats v as indented JSON
+func jsonIndent(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// table writes aligned columns to stdout
+type table struct {
+	w *tabwriter.Writer
+}
+
+// newTable creates a table with the given column headers (none for key/value tables)
+func newTable(headers ...string) *table {
+	t := &table{w: tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)}
+	if len(headers) > 0 {
+		t.row(toInterfaces(headers)...)
+	}
+	return t
+}
+
+// row writes a single table row
+func (t *table) row(values ...interface{}) {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = fmt.Sprint(v)
+	}
+	fmt.Fprintln(t.w, strings.Join(cells, "\t"))
+}
+
+// flush writes the buffered rows
+func (t *table) flush() error {
+	return t.w.Flush()
+}
+
+// toInterfaces converts strings for use as row values
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// printValue writes an arbitrary result: objects as a key/value table,
+// scalars as-is and everything else as indented JSON
+func printValue(w io.Writer, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case string:
+		_, err := fmt.Fprintln(w, value)
+		return err
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		t := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, k := range keys {
+			fmt.Fprintf(t, "%s\t%s\n", k, compactValue(value[k]))
+		}
+		return t.Flush()
+	case float64, bool:
+		_, err := fmt.Fprintln(w, value)
+		return err
+	default:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+}
+
+// compactValue formats a value for a single table cell
+func compactValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case nil:
+		return "-"
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+
+	"github.com/olane-labs/olane-go/pkg/core"
+)
+
+// completionTimeout bounds the network lookups made while completing
+const completionTimeout = 2 * time.Second
+
+// replCommands are the REPL built-ins offered by completion
+var replCommands = []string{"cd", "exit", "help", "jobs", "ls", "peers", "resolve", "whoami"}
+
+// replHelp describes the REPL syntax
+const replHelp = `Calls:
+  <o-address> <method> [{json params}]   call a method
+  <method> [{json params}]               call a method on the address selected with cd
+  ... &                                  run the call in the background; its result prints when done
+
+Commands:
+  cd <o-address>        select an address for bare method calls ('cd' alone clears it)
+  whoami [o-address]    describe a node
+  resolve [o-address]   show the next hop and target
+  ls                    list registry addresses
+  peers                 list connected peers
+  jobs                  list background calls still running
+  help                  show this help
+  exit                  leave the REPL
+
+TAB completes commands, registry addresses and their methods.
+Ctrl-C cancels the running call.
+
+Results print once a call completes. Streaming results are not supported yet:
+they need partial responses in the o-protocol, which are on the roadmap.`
+
+// replCall is a parsed method call
+type replCall struct {
+	address    string
+	method     string
+	params     map[string]interface{}
+	background bool
+}
+
+// parseCall parses '<address> <method> [json] [&]' or, when current is set,
+// '<method> [json] [&]'
+func parseCall(line, current string) (*replCall, error) {
+	call := &replCall{}
+	line = strings.TrimSpace(line)
+	if strings.HasSuffix(line, "&") {
+		call.background = true
+		line = strings.TrimSpace(strings.TrimSuffix(line, "&"))
+	}
+
+	first, rest := splitWord(line)
+	if strings.HasPrefix(first, "o://") {
+		call.address = first
+		call.method, rest = splitWord(rest)
+	} else {
+		call.address = current
+		call.method = first
+	}
+
+	if call.address == "" {
+		return nil, fmt.Errorf("no address: use '<o-address> <method>' or select one with 'cd'")
+	}
+	if call.method == "" {
+		return nil, fmt.Errorf("missing method for %s", call.address)
+	}
+
+	params, err := parseParams(rest)
+	if err != nil {
+		return nil, err
+	}
+	call.params = params
+	return call, nil
+}
+
+// splitWord splits off the first whitespace-separated word
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+	return s, ""
+}
+
+// repl is an interactive session against a network
+type repl struct {
+	client *client
+	flags  *clientFlags
+	rl     *readline.Instance
+
+	// current is the address selected with cd; the completer reads it concurrently
+	current   string
+	currentMu sync.Mutex
+
+	// out serializes writes so background results do not interleave
+	out   io.Writer
+	outMu sync.Mutex
+
+	jobs   map[int]string
+	nextID int
+	jobsMu sync.Mutex
+	wg     sync.WaitGroup
+
+	// registered holds the methods of the addresses last listed by the registry
+	registered   map[string][]string
+	registeredMu sync.Mutex
+
+	ctx context.Context
+}
+
+// runREPL starts an interactive session connected to a leader
+func runREPL(args []string) error {
+	fs := newFlagSet("repl")
+	var f clientFlags
+	f.register(fs)
+	history := fs.String("history", defaultHistoryFile(), "history file (empty disables history)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, positional, 0); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connectCtx, connectCancel := context.WithTimeout(ctx, f.timeout)
+	c, err := newClient(connectCtx, &f)
+	connectCancel()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	r := &repl{client: c, flags: &f, jobs: make(map[int]string), ctx: ctx}
+	comp := newCompleter(replCommands, r.registryAddresses, r.methods, r.currentAddress)
+
+	r.rl, err = readline.NewEx(&readline.Config{
+		Prompt:          r.prompt(),
+		HistoryFile:     *history,
+		AutoComplete:    comp,
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start terminal: %w", err)
+	}
+	defer r.rl.Close()
+	r.out = r.rl.Stdout()
+
+	if f.leader != "" {
+		r.printf("Connected to %s\n", f.leader)
+	}
+	r.printf("Type 'help' for commands.\n")
+
+	for {
+		line, err := r.rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			break
+		}
+		if line == "ls" {
+			comp.invalidate()
+		}
+		if err := r.exec(line); err != nil {
+			r.printf("error: %v\n", err)
+		}
+	}
+
+	r.wg.Wait()
+	return nil
+}
+
+// defaultHistoryFile returns ~/.olane_history, or no file if the home directory is unknown
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".olane_history")
+}
+
+// prompt returns the prompt for the current address
+func (r *repl) prompt() string {
+	current := r.currentAddress()
+	if current == "" {
+		return "olane> "
+	}
+	return current + "> "
+}
+
+// currentAddress returns the address selected with cd
+func (r *repl) currentAddress() string {
+	r.currentMu.Lock()
+	defer r.currentMu.Unlock()
+	return r.current
+}
+
+// printf writes to the terminal without interleaving with other output
+func (r *repl) printf(format string, args ...interface{}) {
+	r.outMu.Lock()
+	defer r.outMu.Unlock()
+	fmt.Fprintf(r.out, format, args...)
+}
+
+// printResult writes a call result to the terminal
+func (r *repl) printResult(prefix string, response *core.OResponse) {
+	r.outMu.Lock()
+	defer r.outMu.Unlock()
+
+	if prefix != "" {
+		fmt.Fprint(r.out, prefix)
+	}
+	if r.flags.json {
+		data, _ := jsonIndent(response)
+		fmt.Fprintln(r.out, data)
+		return
+	}
+	if response.Error != nil {
+		fmt.Fprintf(r.out, "error: %v\n", response.Error)
+		return
+	}
+	if err := printValue(r.out, response.Result); err != nil {
+		fmt.Fprintf(r.out, "error: %v\n", err)
+	}
+}
+
+// foreground returns a context for a foreground command that Ctrl-C cancels
+func (r *repl) foreground() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(r.ctx, r.flags.timeout)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// exec runs a single REPL line
+func (r *repl) exec(line string) error {
+	name, arg := splitWord(line)
+
+	switch name {
+	case "help":
+		r.printf("%s\n", replHelp)
+		return nil
+	case "cd":
+		return r.cd(arg)
+	case "whoami", "resolve":
+		background := strings.HasSuffix(arg, "&")
+		address := strings.TrimSpace(strings.TrimSuffix(arg, "&"))
+		if address == "" {
+			address = r.currentAddress()
+		}
+		if address == "" {
+			return fmt.Errorf("usage: %s <o-address>", name)
+		}
+		if name == "whoami" {
+			return r.call(&replCall{address: address, method: "whoami", background: background})
+		}
+		return r.resolve(address)
+	case "ls":
+		return r.ls()
+	case "peers":
+		return r.peers()
+	case "jobs":
+		return r.listJobs()
+	}
+
+	call, err := parseCall(line, r.currentAddress())
+	if err != nil {
+		return err
+	}
+	return r.call(call)
+}
+
+// cd selects the address used for bare method calls
+func (r *repl) cd(address string) error {
+	if address != "" {
+		parsed, err := core.ParseOAddress(address)
+		if err != nil {
+			return err
+		}
+		address = parsed.String()
+	}
+	r.currentMu.Lock()
+	r.current = address
+	r.currentMu.Unlock()
+	r.rl.SetPrompt(r.prompt())
+	return nil
+}
+
+// call sends a request, waiting for it or running it as a background job
+func (r *repl) call(call *replCall) error {
+	address, err := r.client.target(call.address)
+	if err != nil {
+		return err
+	}
+
+	if !call.background {
+		ctx, cancel := r.foreground()
+		defer cancel()
+		response, err := r.client.node.Use(ctx, address, call.method, call.params, nil)
+		if err != nil {
+			return err
+		}
+		r.printResult("", response)
+		return nil
+	}
+
+	id := r.addJob(call.address + " " + call.method)
+	r.printf("[%d] started\n", id)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.removeJob(id)
+
+		ctx, cancel := context.WithTimeout(r.ctx, r.flags.timeout)
+		defer cancel()
+		response, err := r.client.node.Use(ctx, address, call.method, call.params, nil)
+		if err != nil {
+			r.printf("[%d] error: %v\n", id, err)
+			return
+		}
+		r.printResult(fmt.Sprintf("[%d] done\n", id), response)
+	}()
+	return nil
+}
+
+// resolve prints the next hop and target of an address
+func (r *repl) resolve(value string) error {
+	address, err := r.client.target(value)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.foreground()
+	defer cancel()
+
+	result, err := r.client.node.TranslateAddress(ctx, address)
+	if err != nil {
+		return err
+	}
+	r.printf("Target:    %s\nNext hop:  %s\nVia:       %s\n",
+		result.TargetAddress, result.NextHopAddress,
+		strings.Join(result.NextHopAddress.AllTransports(), ", "))
+	return nil
+}
+
+// ls prints the registry addresses
+func (r *repl) ls() error {
+	addresses, err := r.registryAddresses()
+	sort.Strings(addresses)
+	r.printf("%s\n", strings.Join(addresses, "\n"))
+	if err != nil {
+		return fmt.Errorf("registry unavailable: %w", err)
+	}
+	return nil
+}
+
+// peers prints the connected peers
+func (r *repl) peers() error {
+	h := r.client.node.Host()
+	ids := h.Network().Peers()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		addrs := make([]string, 0)
+		for _, conn := range h.Network().ConnsToPeer(id) {
+			addrs = append(addrs, conn.RemoteMultiaddr().String())
+		}
+		r.printf("%s  %s\n", id, strings.Join(addrs, ","))
+	}
+	return nil
+}
+
+// addJob records a running background call and returns its ID
+func (r *repl) addJob(description string) int {
+	r.jobsMu.Lock()
+	defer r.jobsMu.Unlock()
+	r.nextID++
+	r.jobs[r.nextID] = description
+	return r.nextID
+}
+
+// removeJob forgets a finished background call
+func (r *repl) removeJob(id int) {
+	r.jobsMu.Lock()
+	defer r.jobsMu.Unlock()
+	delete(r.jobs, id)
+}
+
+// listJobs prints the running background calls
+func (r *repl) listJobs() error {
+	r.jobsMu.Lock()
+	ids := make([]int, 0, len(r.jobs))
+	for id := range r.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	lines := make([]string, len(ids))
+	for i, id := range ids {
+		lines[i] = fmt.Sprintf("[%d] %s", id, r.jobs[id])
+	}
+	r.jobsMu.Unlock()
+
+	if len(lines) > 0 {
+		r.printf("%s\n", strings.Join(lines, "\n"))
+	}
+	return nil
+}
+
+// registryAddresses lists the addresses known to the leader's registry,
+// remembering the methods each entry registered for method completion.
+// The leader is always included so completion works before anything registers.
+func (r *repl) registryAddresses() ([]string, error) {
+	addresses := []string{"o://leader"}
+
+	address, err := r.client.target(core.RegistryAddress)
+	if err != nil {
+		return addresses, err
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, completionTimeout)
+	defer cancel()
+
+	response, err := r.client.use(ctx, address, "find_all", nil)
+	if err != nil {
+		return addresses, err
+	}
+
+	r.registeredMu.Lock()
+	r.registered = registryResultMethods(response.Result)
+	r.registeredMu.Unlock()
+	return append(addresses, registryResultAddresses(response.Result)...), nil
+}
+
+// registryResultAddresses extracts the addresses from a registry result
+func registryResultAddresses(result interface{}) []string {
+	var addresses []string
+	for _, fields := range registryResultEntries(result) {
+		addresses = append(addresses, entryAddresses(fields)...)
+	}
+	return addresses
+}
+
+// entryAddresses returns the address and, if it differs, the static address of a registry entry
+func entryAddresses(fields map[string]interface{}) []string {
+	var addresses []string
+	for _, key := range []string{"address", "staticAddress"} {
+		if value, ok := fields[key].(string); ok && value != "" && (len(addresses) == 0 || addresses[0] != value) {
+			addresses = append(addresses, value)
+		}
+	}
+	return addresses
+}
+
+// registryResultMethods extracts the registered method names of each address
+// (and static address) in a registry result
+func registryResultMethods(result interface{}) map[string][]string {
+	methods := make(map[string][]string)
+	for _, fields := range registryResultEntries(result) {
+		described, _ := fields["methods"].(map[string]interface{})
+		if len(described) == 0 {
+			continue
+		}
+		names := []string{"whoami"}
+		for name := range described {
+			names = append(names, name)
+		}
+		for _, address := range entryAddresses(fields) {
+			methods[address] = names
+		}
+	}
+	return methods
+}
+
+// registryResultEntries returns the entries of a registry result, which is
+// either a list of entries or an object with the list under "data"
+func registryResultEntries(result interface{}) []map[string]interface{} {
+	items, ok := result.([]interface{})
+	if !ok {
+		if object, isObject := result.(map[string]interface{}); isObject {
+			items, _ = object["data"].([]interface{})
+		}
+	}
+
+	entries := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if fields, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, fields)
+		}
+	}
+	return entries
+}
+
+// methods lists the methods of an address: the ones it registered with the
+// leader if the registry listed it, otherwise the ones its whoami reports
+func (r *repl) methods(value string) ([]string, error) {
+	r.registeredMu.Lock()
+	registered, ok := r.registered[value]
+	r.registeredMu.Unlock()
+	if ok {
+		return registered, nil
+	}
+
+	address, err := r.client.target(value)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, completionTimeout)
+	defer cancel()
+
+	response, err := r.client.use(ctx, address, "whoami", nil)
+	if err != nil {
+		return nil, err
+	}
+	var whoami core.WhoAmIResponse
+	if err := decodeResult(response.Result, &whoami); err != nil {
+		return nil, err
+	}
+
+	methods := []string{"whoami"}
+	for name := range whoami.Methods {
+		methods = append(methods, name)
+	}
+	return methods, nil
+}
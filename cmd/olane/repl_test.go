@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/olane-labs/olane-go/pkg/core"
+)
+
+func TestParseCall(t *testing.T) {
+	call, err := parseCall(`o://tools/calc add {"a": 1, "b": 2} &`, "")
+	if err != nil {
+		t.Fatalf("Failed to parse call: %v", err)
+	}
+	if call.address != "o://tools/calc" || call.method != "add" {
+		t.Errorf("Expected o://tools/calc add, got %s %s", call.address, call.method)
+	}
+	if call.params["b"] != float64(2) {
+		t.Errorf("Expected params to be decoded, got %v", call.params)
+	}
+	if !call.background {
+		t.Error("Expected trailing & to run the call in the background")
+	}
+
+	call, err = parseCall("whoami", "o://tools/calc")
+	if err != nil {
+		t.Fatalf("Failed to parse call: %v", err)
+	}
+	if call.address != "o://tools/calc" || call.method != "whoami" || call.background {
+		t.Errorf("Expected foreground whoami on current address, got %+v", call)
+	}
+
+	if _, err := parseCall("add", ""); err == nil {
+		t.Error("Expected error for a bare method without a current address")
+	}
+	if _, err := parseCall("o://tools/calc", ""); err == nil {
+		t.Error("Expected error for a missing method")
+	}
+	if _, err := parseCall("o://tools/calc add [1]", ""); err == nil {
+		t.Error("Expected error for non-object params")
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	current := ""
+	c := newCompleter(
+		replCommands,
+		func() ([]string, error) { return []string{"o://tools/calc", "o://tools/clock"}, nil },
+		func(address string) ([]string, error) {
+			if address == "o://tools/calc" {
+				return []string{"add", "subtract"}, nil
+			}
+			return nil, nil
+		},
+		func() string { return current },
+	)
+
+	complete := func(line string) []string {
+		suffixes, _ := c.Do([]rune(line), len([]rune(line)))
+		result := make([]string, len(suffixes))
+		for i, s := range suffixes {
+			result[i] = string(s)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	if got := complete("o://tools/c"); !reflect.DeepEqual(got, []string{"alc ", "lock "}) {
+		t.Errorf("Expected address completions, got %q", got)
+	}
+	if got := complete("o://tools/calc "); !reflect.DeepEqual(got, []string{"add ", "subtract "}) {
+		t.Errorf("Expected method completions, got %q", got)
+	}
+	if got := complete("cd o://tools/cl"); !reflect.DeepEqual(got, []string{"ock "}) {
+		t.Errorf("Expected address completion after cd, got %q", got)
+	}
+	if got := complete("wh"); !reflect.DeepEqual(got, []string{"oami "}) {
+		t.Errorf("Expected command completion, got %q", got)
+	}
+
+	current = "o://tools/calc"
+	if got := complete("su"); !reflect.DeepEqual(got, []string{"btract "}) {
+		t.Errorf("Expected method completion for the current address, got %q", got)
+	}
+}
+
+func TestRegistryResultAddresses(t *testing.T) {
+	result := map[string]interface{}{
+		"data": []interface{}{
+			map[string]interface{}{"address": "o://leader/tools/calc", "staticAddress": "o://calc"},
+			map[string]interface{}{"address": "o://leader/tools/clock"},
+		},
+	}
+
+	got := registryResultAddresses(result)
+	want := []string{"o://leader/tools/calc", "o://calc", "o://leader/tools/clock"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRegistryResultMethods(t *testing.T) {
+	result := map[string]interface{}{
+		"data": []interface{}{
+			map[string]interface{}{
+				"address":       "o://leader/tools/calc",
+				"staticAddress": "o://calc",
+				"methods":       map[string]interface{}{"add": map[string]interface{}{"name": "add"}},
+			},
+			map[string]interface{}{"address": "o://leader/tools/clock"},
+		},
+	}
+
+	got := registryResultMethods(result)
+	for _, address := range []string{"o://leader/tools/calc", "o://calc"} {
+		methods := append([]string(nil), got[address]...)
+		sort.Strings(methods)
+		if !reflect.DeepEqual(methods, []string{"add", "whoami"}) {
+			t.Errorf("Expected %s methods [add whoami], got %v", address, methods)
+		}
+	}
+	if _, ok := got["o://leader/tools/clock"]; ok {
+		t.Error("Expected entries without methods to fall back to whoami")
+	}
+}
+
+func TestREPLCompletionLookups(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := startNode(t, ctx, "o://leader", core.NodeTypeLeader, "")
+	leaderAddr := leader.Transports()[0] + "/p2p/" + leader.ID().String()
+	tool := startNode(t, ctx, "o://tools/calc", core.NodeTypeTool, leaderAddr)
+	tool.Handle("add", func(ctx context.Context, req *core.ORequest) (interface{}, error) {
+		return nil, nil
+	})
+	// Handlers added after start reach the registry when the node commits again
+	if err := tool.Register(ctx); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+
+	flags := &clientFlags{leader: leaderAddr, timeout: 10 * time.Second}
+	c, err := newClient(ctx, flags)
+	if err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer c.close()
+	r := &repl{client: c, flags: flags, jobs: make(map[int]string), ctx: ctx}
+
+	addresses, err := r.registryAddresses()
+	if err != nil {
+		t.Fatalf("Failed to list registry addresses: %v", err)
+	}
+	sort.Strings(addresses)
+	if !reflect.DeepEqual(addresses, []string{"o://leader", "o://tools/calc"}) {
+		t.Errorf("Expected the leader and the tool, got %v", addresses)
+	}
+
+	// Registered methods come from the registry, others from whoami
+	for address, want := range map[string][]string{
+		"o://tools/calc": {"add", "whoami"},
+		"o://leader":     {"whoami"},
+	} {
+		methods, err := r.methods(address)
+		if err != nil {
+			t.Fatalf("Failed to list methods of %s: %v", address, err)
+		}
+		sort.Strings(methods)
+		if !reflect.DeepEqual(methods, want) {
+			t.Errorf("Expected %s methods %v, got %v", address, want, methods)
+		}
+	}
+}
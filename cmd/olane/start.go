@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/olane-labs/olane-go/pkg/core"
+)
+
+// runStart starts a node from a configuration file and runs it until interrupted
+func runStart(args []string) error {
+	fs := newFlagSet("start")
+	configPath := fs.String("config", "", "path to the JSON node configuration")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *configPath == "" {
+		fmt.Fprintln(fs.Output(), "--config is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	fileConfig, err := LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg, err := fileConfig.CoreConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	node := core.NewCoreNode(cfg)
+	if err := node.Start(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Address:    %s\n", node.Address())
+	fmt.Printf("Type:       %s\n", node.Type())
+	fmt.Printf("Peer ID:    %s\n", node.ID())
+	for _, transport := range node.Transports() {
+		fmt.Printf("Listening:  %s/p2p/%s\n", transport, node.ID())
+	}
+
+	<-ctx.Done()
+	fmt.Println("Stopping node...")
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return node.Stop(stopCtx)
+}
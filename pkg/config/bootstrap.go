@@ -0,0 +1,224 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// BootstrapConfig controls how bootstrap peers are dialed and maintained
+type BootstrapConfig struct {
+	// Concurrency is the number of bootstrap peers dialed in parallel
+	Concurrency int
+	// DialTimeout bounds each connection attempt
+	DialTimeout time.Duration
+	// MaxAttempts is the number of attempts per peer before giving up
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles on every retry
+	Backoff time.Duration
+	// MinPeers is the number of successful connections required for bootstrap to be ready (0 never fails)
+	MinPeers int
+	// LowPeerThreshold triggers a background re-bootstrap when the connected peer count falls below it (0 disables)
+	LowPeerThreshold int
+	// CheckInterval is how often the connected peer count is checked
+	CheckInterval time.Duration
+}
+
+// DefaultBootstrapConfig returns the default bootstrap configuration
+func DefaultBootstrapConfig() *BootstrapConfig {
+	return &BootstrapConfig{
+		Concurrency:      8,
+		DialTimeout:      10 * time.Second,
+		MaxAttempts:      3,
+		Backoff:          500 * time.Millisecond,
+		MinPeers:         0,
+		LowPeerThreshold: 0,
+		CheckInterval:    30 * time.Second,
+	}
+}
+
+// withDefaults fills zero values from the default configuration
+func (c *BootstrapConfig) withDefaults() *BootstrapConfig {
+	defaults := DefaultBootstrapConfig()
+	if c == nil {
+		return defaults
+	}
+
+	result := *c
+	if result.Concurrency <= 0 {
+		result.Concurrency = defaults.Concurrency
+	}
+	if result.DialTimeout <= 0 {
+		result.DialTimeout = defaults.DialTimeout
+	}
+	if result.MaxAttempts <= 0 {
+		result.MaxAttempts = defaults.MaxAttempts
+	}
+	if result.Backoff <= 0 {
+		result.Backoff = defaults.Backoff
+	}
+	if result.CheckInterval <= 0 {
+		result.CheckInterval = defaults.CheckInterval
+	}
+	return &result
+}
+
+// BootstrapResult holds the outcome of dialing a single bootstrap peer
+type BootstrapResult struct {
+	Address  string        `json:"address"`
+	PeerID   peer.ID       `json:"peerId"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+}
+
+// BootstrapReport summarizes a bootstrap run
+type BootstrapReport struct {
+	Succeeded []BootstrapResult `json:"succeeded"`
+	Failed    []BootstrapResult `json:"failed"`
+	MinPeers  int               `json:"minPeers"`
+}
+
+// Ready reports whether enough bootstrap peers were reached
+func (r *BootstrapReport) Ready() bool {
+	return len(r.Succeeded) >= r.MinPeers
+}
+
+// Err returns an error describing why bootstrap is not ready, or nil if it is
+func (r *BootstrapReport) Err() error {
+	if r.Ready() {
+		return nil
+	}
+	return fmt.Errorf("connected to %d of %d required bootstrap peers (%d failed)",
+		len(r.Succeeded), r.MinPeers, len(r.Failed))
+}
+
+// parseBootstrapPeers converts bootstrap peer strings to peer address infos
+func parseBootstrapPeers(bootstrapPeers []string) ([]peer.AddrInfo, error) {
+	infos := make([]peer.AddrInfo, 0, len(bootstrapPeers))
+	for _, peerAddr := range bootstrapPeers {
+		ma, err := multiaddr.NewMultiaddr(peerAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap peer address %s: %w", peerAddr, err)
+		}
+
+		peerInfo, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse peer info from %s: %w", peerAddr, err)
+		}
+		infos = append(infos, *peerInfo)
+	}
+	return infos, nil
+}
+
+// BootstrapPeers dials the bootstrap peers in parallel, retrying each with
+// exponential backoff, and reports which peers were reached.
+// An error is only returned for malformed peer addresses; use the report's
+// Ready or Err to check whether the minimum peer count was met.
+func BootstrapPeers(ctx context.Context, h host.Host, bootstrapPeers []string, cfg *BootstrapConfig) (*BootstrapReport, error) {
+	cfg = cfg.withDefaults()
+
+	infos, err := parseBootstrapPeers(bootstrapPeers)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BootstrapResult, len(infos))
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+
+	for i := range infos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = dialWithRetry(ctx, h, infos[i], cfg)
+			results[i].Address = bootstrapPeers[i]
+		}(i)
+	}
+	wg.Wait()
+
+	report := &BootstrapReport{
+		Succeeded: []BootstrapResult{},
+		Failed:    []BootstrapResult{},
+		MinPeers:  cfg.MinPeers,
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			report.Failed = append(report.Failed, result)
+		} else {
+			report.Succeeded = append(report.Succeeded, result)
+		}
+	}
+
+	return report, nil
+}
+
+// dialWithRetry connects to a peer, retrying with exponential backoff
+func dialWithRetry(ctx context.Context, h host.Host, info peer.AddrInfo, cfg *BootstrapConfig) BootstrapResult {
+	result := BootstrapResult{PeerID: info.ID}
+	start := time.Now()
+	backoff := cfg.Backoff
+
+	for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+		result.Attempts = attempt
+
+		dialCtx, cancel := context.WithTimeout(ctx, cfg.DialTimeout)
+		result.Err = h.Connect(dialCtx, info)
+		cancel()
+
+		if result.Err == nil || attempt == cfg.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			result.Duration = time.Since(start)
+			return result
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// MaintainBootstrap re-runs bootstrap whenever the host's connected peer count
+// falls below cfg.LowPeerThreshold. It blocks until ctx is cancelled, so run it
+// in a goroutine. onReport, which may be nil, receives every re-bootstrap report.
+func MaintainBootstrap(ctx context.Context, h host.Host, bootstrapPeers []string, cfg *BootstrapConfig, onReport func(*BootstrapReport)) {
+	cfg = cfg.withDefaults()
+	if cfg.LowPeerThreshold <= 0 || len(bootstrapPeers) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if len(h.Network().Peers()) >= cfg.LowPeerThreshold {
+				continue
+			}
+
+			report, err := BootstrapPeers(ctx, h, bootstrapPeers, cfg)
+			if err != nil {
+				return
+			}
+			if onReport != nil {
+				onReport(report)
+			}
+		}
+	}
+}
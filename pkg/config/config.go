@@ -10,6 +10,7 @@ import (
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
@@ -19,6 +20,7 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
 	"github.com/multiformats/go-multiaddr"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Libp2pConfig holds configuration options for libp2p nodes
@@ -31,16 +33,36 @@ type Libp2pConfig struct {
 	Identity crypto.PrivKey
 	// ConnMgr configures the connection manager
 	ConnMgr *connmgr.BasicConnMgr
+	// ResourceLimits configures the libp2p resource manager (nil keeps libp2p's auto-scaled defaults)
+	ResourceLimits *ResourceLimits
+	// ConnectionGater restricts which peers and addresses may connect (nil allows all)
+	ConnectionGater *ConnectionGater
 	// EnableRelay enables circuit relay functionality
 	EnableRelay bool
 	// EnableDHT enables the Kademlia DHT
 	EnableDHT bool
 	// EnablePubsub enables gossipsub
 	EnablePubsub bool
+	// MessageValidator checks GossipSub messages against per-topic rules (nil accepts any signed message)
+	MessageValidator *MessageValidator
+	// PeerScore enables GossipSub peer scoring (nil disables it)
+	PeerScore *PeerScoreConfig
+	// MaxMessageSize caps GossipSub message size (0 keeps the 1 MiB default)
+	MaxMessageSize int
 	// DHTProtocolPrefix sets the DHT protocol prefix
 	DHTProtocolPrefix protocol.ID
 	// KBucketSize sets the DHT k-bucket size
 	KBucketSize int
+	// Bootstrap controls bootstrap dialing, retries and re-bootstrapping
+	Bootstrap *BootstrapConfig
+	// EnableMDNS enables zero-config peer discovery on the local network (disable in production)
+	EnableMDNS bool
+	// MDNSServiceName scopes mDNS discovery; nodes only find peers using the same name
+	MDNSServiceName string
+	// PrometheusRegisterer receives libp2p's metrics, including the resource manager's (nil uses the default registerer)
+	PrometheusRegisterer prometheus.Registerer
+	// Logger receives warnings and debug output from node creation (nil discards it)
+	Logger Logger
 }
 
 // DefaultLibp2pConfig returns a default configuration for libp2p nodes
@@ -65,6 +87,7 @@ func DefaultLibp2pConfig() *Libp2pConfig {
 	return &Libp2pConfig{
 		Listeners:         []string{"/ip4/0.0.0.0/tcp/0"},
 		BootstrapPeers:    []string{},
+		Bootstrap:         DefaultBootstrapConfig(),
 		Identity:          priv,
 		ConnMgr:           connMgr,
 		EnableRelay:       true,
@@ -72,6 +95,8 @@ func DefaultLibp2pConfig() *Libp2pConfig {
 		EnablePubsub:      true,
 		DHTProtocolPrefix: "/ipfs/kad/1.0.0",
 		KBucketSize:       20,
+		EnableMDNS:        false,
+		MDNSServiceName:   DefaultMDNSServiceName,
 	}
 }
 
@@ -82,6 +107,11 @@ func CreateNode(ctx context.Context, config *Libp2pConfig) (host.Host, *dht.Ipfs
 		config = DefaultLibp2pConfig()
 	}
 
+	logger := config.Logger
+	if logger == nil {
+		logger = NoOpLogger()
+	}
+
 	// Convert listener strings to multiaddrs
 	var listenAddrs []multiaddr.Multiaddr
 	for _, addr := range config.Listeners {
@@ -108,9 +138,28 @@ func CreateNode(ctx context.Context, config *Libp2pConfig) (host.Host, *dht.Ipfs
 		libp2p.ConnectionManager(config.ConnMgr),
 		// Enable NAT traversal
 		libp2p.NATPortMap(),
-		// Enable AutoRelay if configured
 	}
 
+	if config.PrometheusRegisterer != nil {
+		opts = append(opts, libp2p.PrometheusRegisterer(config.PrometheusRegisterer))
+	}
+
+	var rm network.ResourceManager
+	if config.ResourceLimits != nil {
+		var err error
+		rm, err = newResourceManager(config.ResourceLimits)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create resource manager: %w", err)
+		}
+		opts = append(opts, libp2p.ResourceManager(rm))
+	}
+
+	if config.ConnectionGater != nil {
+		config.ConnectionGater.setDefaultLogger(logger)
+		opts = append(opts, libp2p.ConnectionGater(config.ConnectionGater))
+	}
+
+	// Enable AutoRelay if configured
 	if config.EnableRelay {
 		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays([]peer.AddrInfo{}))
 	}
@@ -118,8 +167,13 @@ func CreateNode(ctx context.Context, config *Libp2pConfig) (host.Host, *dht.Ipfs
 	// Create the libp2p host
 	h, err := libp2p.New(opts...)
 	if err != nil {
+		// The host only takes ownership of the resource manager once it is built
+		if rm != nil {
+			rm.Close()
+		}
 		return nil, nil, nil, fmt.Errorf("failed to create libp2p host: %w", err)
 	}
+	logger.Debugf("Created libp2p host %s listening on %v", h.ID(), h.Addrs())
 
 	var kademliaDHT *dht.IpfsDHT
 	var gossipSub *pubsub.PubSub
@@ -142,14 +196,20 @@ func CreateNode(ctx context.Context, config *Libp2pConfig) (host.Host, *dht.Ipfs
 			kademliaDHT.Close()
 			return nil, nil, nil, fmt.Errorf("failed to bootstrap DHT: %w", err)
 		}
+		logger.Debugf("DHT started with protocol prefix %s", config.DHTProtocolPrefix)
 	}
 
 	// Initialize PubSub if enabled
 	if config.EnablePubsub {
-		gossipSub, err = pubsub.NewGossipSub(ctx, h,
-			pubsub.WithMessageSigning(true),
-			pubsub.WithStrictSignatureVerification(true),
-		)
+		if config.MessageValidator != nil {
+			config.MessageValidator.setLocalPeer(h.ID())
+			config.MessageValidator.setDefaultLogger(logger)
+		}
+		if config.PeerScore != nil && (config.PeerScore.Params == nil || config.PeerScore.Thresholds == nil) {
+			err = fmt.Errorf("peer score params and thresholds are required")
+		} else {
+			gossipSub, err = pubsub.NewGossipSub(ctx, h, pubsubOptions(config)...)
+		}
 		if err != nil {
 			h.Close()
 			if kademliaDHT != nil {
@@ -162,28 +222,27 @@ func CreateNode(ctx context.Context, config *Libp2pConfig) (host.Host, *dht.Ipfs
 	return h, kademliaDHT, gossipSub, nil
 }
 
-// ConnectToBootstrapPeers connects the host to bootstrap peers
-func ConnectToBootstrapPeers(ctx context.Context, h host.Host, bootstrapPeers []string) error {
+// ConnectToBootstrapPeers connects the host to bootstrap peers using the default
+// bootstrap configuration. Use BootstrapPeers for retries tuning and a detailed report.
+// Failed peers are reported to logger, which may be nil.
+func ConnectToBootstrapPeers(ctx context.Context, h host.Host, bootstrapPeers []string, logger Logger) error {
 	if len(bootstrapPeers) == 0 {
 		return nil
 	}
 
-	for _, peerAddr := range bootstrapPeers {
-		ma, err := multiaddr.NewMultiaddr(peerAddr)
-		if err != nil {
-			return fmt.Errorf("invalid bootstrap peer address %s: %w", peerAddr, err)
-		}
+	if logger == nil {
+		logger = NoOpLogger()
+	}
 
-		peerInfo, err := peer.AddrInfoFromP2pAddr(ma)
-		if err != nil {
-			return fmt.Errorf("failed to parse peer info from %s: %w", peerAddr, err)
-		}
+	report, err := BootstrapPeers(ctx, h, bootstrapPeers, nil)
+	if err != nil {
+		return err
+	}
 
-		if err := h.Connect(ctx, *peerInfo); err != nil {
-			// Log warning but don't fail - bootstrap connection might be temporary
-			fmt.Printf("Warning: failed to connect to bootstrap peer %s: %v\n", peerAddr, err)
-		}
+	for _, failed := range report.Failed {
+		// Log warning but don't fail - bootstrap connection might be temporary
+		logger.Warnf("Failed to connect to bootstrap peer %s: %v", failed.Address, failed.Err)
 	}
 
-	return nil
+	return report.Err()
 }
@@ -2,10 +2,12 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
 )
 
@@ -164,14 +166,187 @@ func TestConnectToBootstrapPeers(t *testing.T) {
 	}()
 
 	// Test with empty bootstrap peers
-	err = ConnectToBootstrapPeers(ctx, h, []string{})
+	err = ConnectToBootstrapPeers(ctx, h, []string{}, nil)
 	if err != nil {
 		t.Errorf("Expected no error with empty bootstrap peers, got: %v", err)
 	}
 
 	// Test with invalid bootstrap peer
-	err = ConnectToBootstrapPeers(ctx, h, []string{"invalid-multiaddr"})
+	err = ConnectToBootstrapPeers(ctx, h, []string{"invalid-multiaddr"}, nil)
 	if err == nil {
 		t.Error("Expected error with invalid bootstrap peer")
 	}
 }
+
+func TestResourceLimitsPresets(t *testing.T) {
+	small, err := NewResourceLimits(ResourcePresetSmall).Build()
+	if err != nil {
+		t.Fatalf("Failed to build small limits: %v", err)
+	}
+	large, err := NewResourceLimits(ResourcePresetLarge).Build()
+	if err != nil {
+		t.Fatalf("Failed to build large limits: %v", err)
+	}
+
+	if small.ToPartialLimitConfig().System.Memory >= large.ToPartialLimitConfig().System.Memory {
+		t.Error("Expected small preset to allow less memory than large preset")
+	}
+
+	if _, err := NewResourceLimits("huge").Build(); err == nil {
+		t.Error("Expected error with unknown preset")
+	}
+}
+
+func TestCreateNodeWithResourceLimits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	limits := NewResourceLimits(ResourcePresetSmall)
+	limits.MaxStreamsPerPeer = 32
+	limits.SetProtocolLimit("/o/tools/calculator", ProtocolLimit{Streams: 16, StreamsPerPeer: 4})
+
+	config := DefaultLibp2pConfig()
+	config.EnableDHT = false
+	config.EnablePubsub = false
+	config.ResourceLimits = limits
+
+	h, _, _, err := CreateNode(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to create node with resource limits: %v", err)
+	}
+	defer h.Close()
+
+	usage, err := ResourceUsage(h)
+	if err != nil {
+		t.Fatalf("Failed to get resource usage: %v", err)
+	}
+	if usage.System.NumConnsInbound != 0 {
+		t.Errorf("Expected no inbound connections, got %d", usage.System.NumConnsInbound)
+	}
+}
+
+func TestBootstrapPeersReport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	newConfig := func() *Libp2pConfig {
+		config := DefaultLibp2pConfig()
+		config.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+		config.EnableDHT = false
+		config.EnablePubsub = false
+		config.EnableRelay = false
+		return config
+	}
+
+	target, _, _, err := CreateNode(ctx, newConfig())
+	if err != nil {
+		t.Fatalf("Failed to create target node: %v", err)
+	}
+	defer target.Close()
+
+	h, _, _, err := CreateNode(ctx, newConfig())
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	defer h.Close()
+
+	reachable := fmt.Sprintf("%s/p2p/%s", target.Addrs()[0], target.ID())
+	// A fresh peer ID that nobody listens for on a closed port
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	stranger, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to derive peer ID: %v", err)
+	}
+	unreachable := fmt.Sprintf("/ip4/127.0.0.1/tcp/1/p2p/%s", stranger)
+
+	report, err := BootstrapPeers(ctx, h, []string{reachable, unreachable}, &BootstrapConfig{
+		DialTimeout: 2 * time.Second,
+		MaxAttempts: 2,
+		Backoff:     10 * time.Millisecond,
+		MinPeers:    1,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected bootstrap error: %v", err)
+	}
+
+	if len(report.Succeeded) != 1 || report.Succeeded[0].PeerID != target.ID() {
+		t.Errorf("Expected one successful peer %s, got %+v", target.ID(), report.Succeeded)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Attempts != 2 {
+		t.Errorf("Expected one failed peer after 2 attempts, got %+v", report.Failed)
+	}
+	if !report.Ready() {
+		t.Errorf("Expected bootstrap to be ready: %v", report.Err())
+	}
+
+	report.MinPeers = 2
+	if report.Ready() || report.Err() == nil {
+		t.Error("Expected bootstrap not to be ready with 2 required peers")
+	}
+}
+
+func TestMaintainBootstrap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	newConfig := func() *Libp2pConfig {
+		config := DefaultLibp2pConfig()
+		config.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+		config.EnableDHT = false
+		config.EnablePubsub = false
+		config.EnableRelay = false
+		return config
+	}
+
+	target, _, _, err := CreateNode(ctx, newConfig())
+	if err != nil {
+		t.Fatalf("Failed to create target node: %v", err)
+	}
+	defer target.Close()
+
+	h, _, _, err := CreateNode(ctx, newConfig())
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	defer h.Close()
+
+	bootstrap := []string{fmt.Sprintf("%s/p2p/%s", target.Addrs()[0], target.ID())}
+	reports := make(chan *BootstrapReport, 4)
+	maintainCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		MaintainBootstrap(maintainCtx, h, bootstrap, &BootstrapConfig{
+			DialTimeout:      2 * time.Second,
+			LowPeerThreshold: 1,
+			CheckInterval:    50 * time.Millisecond,
+		}, func(report *BootstrapReport) {
+			reports <- report
+		})
+	}()
+
+	// The host starts with no peers, and reconnects after losing them
+	for i := 0; i < 2; i++ {
+		select {
+		case report := <-reports:
+			if len(report.Succeeded) != 1 || report.Succeeded[0].PeerID != target.ID() {
+				t.Fatalf("Expected re-bootstrap to reach %s, got %+v", target.ID(), report)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for re-bootstrap %d", i+1)
+		}
+		if err := h.Network().ClosePeer(target.ID()); err != nil {
+			t.Fatalf("Failed to disconnect: %v", err)
+		}
+	}
+
+	stop()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("Expected MaintainBootstrap to return once its context is cancelled")
+	}
+}
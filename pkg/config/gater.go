@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Gating denial reasons
+const (
+	DenyReasonPeerDenied     = "peer-denied"
+	DenyReasonPeerNotAllowed = "peer-not-allowed"
+	DenyReasonAddrDenied     = "addr-denied"
+	DenyReasonAddrNotAllowed = "addr-not-allowed"
+	DenyReasonAuthorizer     = "authorizer"
+)
+
+// PeerAuthorizer decides whether a peer may connect.
+// It lets an external source of truth, such as a list of enrolled peers, take part in gating.
+// It runs before any protocol is negotiated, so it cannot admit a peer for one protocol only.
+// Implementations are called on the dial and handshake paths and must not block.
+type PeerAuthorizer interface {
+	AuthorizePeer(p peer.ID) (allowed bool, reason string)
+}
+
+// PeerAuthorizerFunc adapts a function to the PeerAuthorizer interface
+type PeerAuthorizerFunc func(p peer.ID) (bool, string)
+
+// AuthorizePeer implements PeerAuthorizer
+func (f PeerAuthorizerFunc) AuthorizePeer(p peer.ID) (bool, string) {
+	return f(p)
+}
+
+// GaterConfig holds the policy for a ConnectionGater
+type GaterConfig struct {
+	// AllowPeers, when non-empty, restricts connections to these peer IDs
+	AllowPeers []string
+	// DenyPeers lists peer IDs that are always rejected
+	DenyPeers []string
+	// AllowCIDRs, when non-empty, restricts connections to addresses in these networks
+	AllowCIDRs []string
+	// DenyCIDRs lists networks whose addresses are always rejected
+	DenyCIDRs []string
+	// Authorizer is consulted after the static lists, if set
+	Authorizer PeerAuthorizer
+	// Logger receives a message for every denied connection
+	Logger Logger
+}
+
+// GaterStats holds counters for denied connections
+type GaterStats struct {
+	DeniedDials   uint64            `json:"deniedDials"`
+	DeniedAccepts uint64            `json:"deniedAccepts"`
+	DeniedReasons map[string]uint64 `json:"deniedReasons"`
+}
+
+// ConnectionGater implements the libp2p connmgr.ConnectionGater interface
+// using peer ID allow/deny lists, CIDR filters and an optional PeerAuthorizer.
+// Deny rules always take precedence over allow rules.
+type ConnectionGater struct {
+	allowPeers map[peer.ID]struct{}
+	denyPeers  map[peer.ID]struct{}
+	allowNets  []*net.IPNet
+	denyNets   []*net.IPNet
+	authorizer PeerAuthorizer
+	logger     Logger
+
+	deniedDials   uint64
+	deniedAccepts uint64
+	deniedReasons map[string]uint64
+
+	mu sync.RWMutex
+}
+
+// NewConnectionGater creates a new ConnectionGater from the given policy
+func NewConnectionGater(cfg *GaterConfig) (*ConnectionGater, error) {
+	if cfg == nil {
+		cfg = &GaterConfig{}
+	}
+
+	g := &ConnectionGater{
+		allowPeers:    make(map[peer.ID]struct{}),
+		denyPeers:     make(map[peer.ID]struct{}),
+		authorizer:    cfg.Authorizer,
+		logger:        cfg.Logger,
+		deniedReasons: make(map[string]uint64),
+	}
+
+	for _, s := range cfg.AllowPeers {
+		id, err := peer.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed peer ID %s: %w", s, err)
+		}
+		g.allowPeers[id] = struct{}{}
+	}
+
+	for _, s := range cfg.DenyPeers {
+		id, err := peer.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid denied peer ID %s: %w", s, err)
+		}
+		g.denyPeers[id] = struct{}{}
+	}
+
+	for _, s := range cfg.AllowCIDRs {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR %s: %w", s, err)
+		}
+		g.allowNets = append(g.allowNets, ipNet)
+	}
+
+	for _, s := range cfg.DenyCIDRs {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid denied CIDR %s: %w", s, err)
+		}
+		g.denyNets = append(g.denyNets, ipNet)
+	}
+
+	return g, nil
+}
+
+// AllowPeer adds a peer to the allowlist and removes it from the denylist
+func (g *ConnectionGater) AllowPeer(p peer.ID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.denyPeers, p)
+	g.allowPeers[p] = struct{}{}
+}
+
+// DenyPeer adds a peer to the denylist and removes it from the allowlist
+func (g *ConnectionGater) DenyPeer(p peer.ID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.allowPeers, p)
+	g.denyPeers[p] = struct{}{}
+}
+
+// ForgetPeer removes a peer from both the allowlist and the denylist
+func (g *ConnectionGater) ForgetPeer(p peer.ID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.allowPeers, p)
+	delete(g.denyPeers, p)
+}
+
+// setDefaultLogger sets the logger if none was configured
+func (g *ConnectionGater) setDefaultLogger(logger Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.logger == nil {
+		g.logger = logger
+	}
+}
+
+// SetAuthorizer replaces the peer authorizer (nil disables it)
+func (g *ConnectionGater) SetAuthorizer(authorizer PeerAuthorizer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.authorizer = authorizer
+}
+
+// Stats returns a snapshot of the denial counters
+func (g *ConnectionGater) Stats() GaterStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	reasons := make(map[string]uint64, len(g.deniedReasons))
+	for k, v := range g.deniedReasons {
+		reasons[k] = v
+	}
+
+	return GaterStats{
+		DeniedDials:   g.deniedDials,
+		DeniedAccepts: g.deniedAccepts,
+		DeniedReasons: reasons,
+	}
+}
+
+// checkPeer applies the peer ID rules and the authorizer
+func (g *ConnectionGater) checkPeer(p peer.ID) (bool, string) {
+	g.mu.RLock()
+	_, denied := g.denyPeers[p]
+	_, allowed := g.allowPeers[p]
+	hasAllowlist := len(g.allowPeers) > 0
+	authorizer := g.authorizer
+	g.mu.RUnlock()
+
+	if denied {
+		return false, DenyReasonPeerDenied
+	}
+	if hasAllowlist && !allowed {
+		return false, DenyReasonPeerNotAllowed
+	}
+	if authorizer != nil {
+		if ok, reason := authorizer.AuthorizePeer(p); !ok {
+			if reason == "" {
+				reason = DenyReasonAuthorizer
+			}
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+// checkAddr applies the CIDR rules. Addresses without an IP component
+// (e.g. circuit or DNS addresses) are only subject to the allowlist.
+func (g *ConnectionGater) checkAddr(addr multiaddr.Multiaddr) (bool, string) {
+	if addr == nil {
+		return true, ""
+	}
+
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		if len(g.allowNets) > 0 {
+			return false, DenyReasonAddrNotAllowed
+		}
+		return true, ""
+	}
+
+	for _, n := range g.denyNets {
+		if n.Contains(ip) {
+			return false, DenyReasonAddrDenied
+		}
+	}
+
+	if len(g.allowNets) == 0 {
+		return true, ""
+	}
+	for _, n := range g.allowNets {
+		if n.Contains(ip) {
+			return true, ""
+		}
+	}
+	return false, DenyReasonAddrNotAllowed
+}
+
+// deny records and logs a denied connection
+func (g *ConnectionGater) deny(dir network.Direction, p peer.ID, addr multiaddr.Multiaddr, reason string) bool {
+	g.mu.Lock()
+	if dir == network.DirInbound {
+		g.deniedAccepts++
+	} else {
+		g.deniedDials++
+	}
+	g.deniedReasons[reason]++
+	logger := g.logger
+	g.mu.Unlock()
+
+	if logger == nil {
+		return false
+	}
+
+	target := p.String()
+	if p == "" {
+		target = "unknown peer"
+	}
+	if addr != nil {
+		target = fmt.Sprintf("%s at %s", target, addr)
+	}
+	logger.Warnf("Denied %s connection with %s: %s", dir, target, reason)
+	return false
+}
+
+// InterceptPeerDial implements connmgr.ConnectionGater
+func (g *ConnectionGater) InterceptPeerDial(p peer.ID) bool {
+	if ok, reason := g.checkPeer(p); !ok {
+		return g.deny(network.DirOutbound, p, nil, reason)
+	}
+	return true
+}
+
+// InterceptAddrDial implements connmgr.ConnectionGater
+func (g *ConnectionGater) InterceptAddrDial(p peer.ID, addr multiaddr.Multiaddr) bool {
+	if ok, reason := g.checkAddr(addr); !ok {
+		return g.deny(network.DirOutbound, p, addr, reason)
+	}
+	return true
+}
+
+// InterceptAccept implements connmgr.ConnectionGater
+func (g *ConnectionGater) InterceptAccept(addrs network.ConnMultiaddrs) bool {
+	remote := addrs.RemoteMultiaddr()
+	if ok, reason := g.checkAddr(remote); !ok {
+		return g.deny(network.DirInbound, "", remote, reason)
+	}
+	return true
+}
+
+// InterceptSecured implements connmgr.ConnectionGater.
+// Inbound peers are only known after the security handshake, so this is
+// where peer rules are applied to accepted connections.
+func (g *ConnectionGater) InterceptSecured(dir network.Direction, p peer.ID, addrs network.ConnMultiaddrs) bool {
+	if dir != network.DirInbound {
+		return true
+	}
+	if ok, reason := g.checkPeer(p); !ok {
+		return g.deny(dir, p, addrs.RemoteMultiaddr(), reason)
+	}
+	return true
+}
+
+// InterceptUpgraded implements connmgr.ConnectionGater
+func (g *ConnectionGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// testConnAddrs implements network.ConnMultiaddrs for gater tests
+type testConnAddrs struct {
+	local, remote multiaddr.Multiaddr
+}
+
+func (a testConnAddrs) LocalMultiaddr() multiaddr.Multiaddr  { return a.local }
+func (a testConnAddrs) RemoteMultiaddr() multiaddr.Multiaddr { return a.remote }
+
+func testPeerID(t *testing.T) peer.ID {
+	t.Helper()
+	priv, _, err := crypto.GenerateKeyPairWithReader(crypto.Ed25519, -1, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to derive peer ID: %v", err)
+	}
+	return id
+}
+
+func TestConnectionGaterPeerLists(t *testing.T) {
+	allowed := testPeerID(t)
+	denied := testPeerID(t)
+	other := testPeerID(t)
+
+	g, err := NewConnectionGater(&GaterConfig{
+		AllowPeers: []string{allowed.String()},
+		DenyPeers:  []string{denied.String()},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gater: %v", err)
+	}
+
+	if !g.InterceptPeerDial(allowed) {
+		t.Error("Expected allowed peer to be dialable")
+	}
+	if g.InterceptPeerDial(denied) {
+		t.Error("Expected denied peer to be rejected")
+	}
+	if g.InterceptPeerDial(other) {
+		t.Error("Expected peer outside the allowlist to be rejected")
+	}
+	if g.InterceptSecured(network.DirInbound, other, testConnAddrs{}) {
+		t.Error("Expected inbound peer outside the allowlist to be rejected")
+	}
+
+	stats := g.Stats()
+	if stats.DeniedDials != 2 || stats.DeniedAccepts != 1 {
+		t.Errorf("Expected 2 denied dials and 1 denied accept, got %+v", stats)
+	}
+	if stats.DeniedReasons[DenyReasonPeerNotAllowed] != 2 {
+		t.Errorf("Expected 2 %s denials, got %d", DenyReasonPeerNotAllowed, stats.DeniedReasons[DenyReasonPeerNotAllowed])
+	}
+
+	g.AllowPeer(other)
+	if !g.InterceptPeerDial(other) {
+		t.Error("Expected peer to be dialable after AllowPeer")
+	}
+}
+
+func TestConnectionGaterCIDR(t *testing.T) {
+	g, err := NewConnectionGater(&GaterConfig{
+		AllowCIDRs: []string{"127.0.0.0/8", "10.0.0.0/8"},
+		DenyCIDRs:  []string{"10.1.0.0/16"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gater: %v", err)
+	}
+
+	tests := []struct {
+		addr  string
+		allow bool
+	}{
+		{"/ip4/127.0.0.1/tcp/4001", true},
+		{"/ip4/10.2.3.4/tcp/4001", true},
+		{"/ip4/10.1.2.3/tcp/4001", false},
+		{"/ip4/192.168.1.1/tcp/4001", false},
+	}
+
+	for _, tt := range tests {
+		ma := multiaddr.StringCast(tt.addr)
+		if got := g.InterceptAccept(testConnAddrs{remote: ma}); got != tt.allow {
+			t.Errorf("InterceptAccept(%s) = %t, expected %t", tt.addr, got, tt.allow)
+		}
+	}
+
+	if _, err := NewConnectionGater(&GaterConfig{DenyCIDRs: []string{"not-a-cidr"}}); err == nil {
+		t.Error("Expected error with invalid CIDR")
+	}
+}
+
+func TestConnectionGaterAuthorizer(t *testing.T) {
+	registered := testPeerID(t)
+	stranger := testPeerID(t)
+
+	g, err := NewConnectionGater(&GaterConfig{
+		Authorizer: PeerAuthorizerFunc(func(p peer.ID) (bool, string) {
+			if p == registered {
+				return true, ""
+			}
+			return false, "not-registered"
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create gater: %v", err)
+	}
+
+	if !g.InterceptPeerDial(registered) {
+		t.Error("Expected registered peer to be allowed")
+	}
+	if g.InterceptPeerDial(stranger) {
+		t.Error("Expected unregistered peer to be rejected")
+	}
+	if g.Stats().DeniedReasons["not-registered"] != 1 {
+		t.Error("Expected authorizer reason to be counted")
+	}
+}
+
+func TestCreateNodeWithConnectionGater(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	newConfig := func() *Libp2pConfig {
+		cfg := DefaultLibp2pConfig()
+		cfg.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+		cfg.EnableDHT = false
+		cfg.EnablePubsub = false
+		cfg.EnableRelay = false
+		return cfg
+	}
+
+	h1, _, _, err := CreateNode(ctx, newConfig())
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	defer h1.Close()
+
+	gater, err := NewConnectionGater(&GaterConfig{DenyPeers: []string{h1.ID().String()}})
+	if err != nil {
+		t.Fatalf("Failed to create gater: %v", err)
+	}
+	cfg := newConfig()
+	cfg.ConnectionGater = gater
+
+	h2, _, _, err := CreateNode(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create gated node: %v", err)
+	}
+	defer h2.Close()
+
+	if err := h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}); err == nil {
+		t.Error("Expected dial to denied peer to fail")
+	}
+	if gater.Stats().DeniedDials == 0 {
+		t.Error("Expected denied dial to be counted")
+	}
+}
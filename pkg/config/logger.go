@@ -0,0 +1,31 @@
+package config
+
+// Logger is the logging interface used by the config package.
+// It has the same method set as core.Logger so either can be passed around freely.
+type Logger interface {
+	Debug(args ...interface{})
+	Info(args ...interface{})
+	Warn(args ...interface{})
+	Error(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// noopLogger discards all log output
+type noopLogger struct{}
+
+func (noopLogger) Debug(args ...interface{})                 {}
+func (noopLogger) Info(args ...interface{})                  {}
+func (noopLogger) Warn(args ...interface{})                  {}
+func (noopLogger) Error(args ...interface{})                 {}
+func (noopLogger) Debugf(format string, args ...interface{}) {}
+func (noopLogger) Infof(format string, args ...interface{})  {}
+func (noopLogger) Warnf(format string, args ...interface{})  {}
+func (noopLogger) Errorf(format string, args ...interface{}) {}
+
+// NoOpLogger returns a logger that discards everything
+func NoOpLogger() Logger {
+	return noopLogger{}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+)
+
+// DefaultMDNSServiceName is the mDNS service name used when no network name is set
+const DefaultMDNSServiceName = "_olane._udp"
+
+// mdnsConnectTimeout bounds each connection attempt to a discovered peer
+const mdnsConnectTimeout = 10 * time.Second
+
+// MDNSServiceNameForNetwork returns the mDNS service name for a network name.
+// Nodes only discover peers advertising the same service name, so scoping it
+// by network keeps separate development clusters on one LAN apart.
+func MDNSServiceNameForNetwork(networkName string) string {
+	if networkName == "" {
+		return DefaultMDNSServiceName
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(networkName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return fmt.Sprintf("_olane-%s._udp", b.String())
+}
+
+// MDNSNotifee connects to peers found via mDNS and forwards them to an optional callback
+type MDNSNotifee struct {
+	ctx     context.Context
+	host    host.Host
+	onFound func(peer.AddrInfo)
+}
+
+// HandlePeerFound implements mdns.Notifee
+func (m *MDNSNotifee) HandlePeerFound(info peer.AddrInfo) {
+	if info.ID == m.host.ID() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(m.ctx, mdnsConnectTimeout)
+		defer cancel()
+
+		if err := m.host.Connect(ctx, info); err != nil {
+			return
+		}
+		if m.onFound != nil {
+			m.onFound(info)
+		}
+	}()
+}
+
+// StartMDNS starts mDNS discovery on the host. Discovered peers are connected to
+// automatically and then passed to onFound, which may be nil.
+// The returned service must be closed to stop advertising.
+func StartMDNS(ctx context.Context, h host.Host, serviceName string, onFound func(peer.AddrInfo)) (mdns.Service, error) {
+	if serviceName == "" {
+		serviceName = DefaultMDNSServiceName
+	}
+
+	notifee := &MDNSNotifee{ctx: ctx, host: h, onFound: onFound}
+	service := mdns.NewMdnsService(h, serviceName, notifee)
+	if err := service.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start mDNS discovery: %w", err)
+	}
+	return service, nil
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Message rejection reasons
+const (
+	RejectReasonSize   = "size"
+	RejectReasonSchema = "schema"
+	RejectReasonSender = "sender"
+)
+
+// MessageSchema checks a message's payload, returning an error if it is malformed
+type MessageSchema func(msg *pubsub.Message) error
+
+// JSONObjectSchema returns a MessageSchema that requires a JSON object containing the given fields
+func JSONObjectSchema(required ...string) MessageSchema {
+	return func(msg *pubsub.Message) error {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(msg.Data, &object); err != nil {
+			return fmt.Errorf("payload is not a JSON object: %w", err)
+		}
+		for _, field := range required {
+			if _, ok := object[field]; !ok {
+				return fmt.Errorf("payload is missing field %q", field)
+			}
+		}
+		return nil
+	}
+}
+
+// TopicValidator holds the validation rules for messages on matching topics.
+// Exactly one of Topic and Prefix must be set.
+type TopicValidator struct {
+	// Topic matches a single topic by name
+	Topic string
+	// Prefix matches every topic starting with it, e.g. "/o/" for all address topics
+	Prefix string
+	// MaxSize rejects payloads larger than this many bytes (0 means no limit)
+	MaxSize int
+	// Schema rejects payloads it returns an error for
+	Schema MessageSchema
+	// Senders, if set, must authorize the signer of every message, e.g. against the
+	// leader's registry. Messages published by the local node are always allowed.
+	Senders PeerAuthorizer
+}
+
+// matches reports whether the validator applies to topic
+func (v *TopicValidator) matches(topic string) bool {
+	if v.Topic != "" {
+		return v.Topic == topic
+	}
+	return strings.HasPrefix(topic, v.Prefix)
+}
+
+// ValidatorStats holds counters for rejected messages
+type ValidatorStats struct {
+	Rejected        uint64            `json:"rejected"`
+	RejectedReasons map[string]uint64 `json:"rejectedReasons"`
+}
+
+// MessageValidator validates GossipSub messages against per-topic rules.
+// Rejected messages are dropped and count against the sender's peer score.
+type MessageValidator struct {
+	validators []*TopicValidator
+	self       peer.ID
+	logger     Logger
+
+	rejected        uint64
+	rejectedReasons map[string]uint64
+
+	mu sync.RWMutex
+}
+
+// NewMessageValidator creates a MessageValidator with the given topic rules
+func NewMessageValidator(validators ...*TopicValidator) (*MessageValidator, error) {
+	v := &MessageValidator{rejectedReasons: make(map[string]uint64)}
+	for _, tv := range validators {
+		if err := v.AddValidator(tv); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// AddValidator adds rules for a topic or topic prefix.
+// Every matching validator must accept a message for it to be delivered.
+func (v *MessageValidator) AddValidator(tv *TopicValidator) error {
+	if tv == nil {
+		return fmt.Errorf("validator must not be nil")
+	}
+	if (tv.Topic == "") == (tv.Prefix == "") {
+		return fmt.Errorf("validator must set exactly one of topic and prefix")
+	}
+	if tv.MaxSize < 0 {
+		return fmt.Errorf("invalid max size %d for %s%s", tv.MaxSize, tv.Topic, tv.Prefix)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.validators = append(v.validators, tv)
+	return nil
+}
+
+// setLocalPeer records the ID of the node whose messages skip the sender check
+func (v *MessageValidator) setLocalPeer(id peer.ID) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.self = id
+}
+
+// setDefaultLogger sets the logger unless one has already been set
+func (v *MessageValidator) setDefaultLogger(logger Logger) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.logger == nil {
+		v.logger = logger
+	}
+}
+
+// Validate implements pubsub.ValidatorEx
+func (v *MessageValidator) Validate(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	topic := msg.GetTopic()
+
+	v.mu.RLock()
+	self := v.self
+	var matched []*TopicValidator
+	for _, tv := range v.validators {
+		if tv.matches(topic) {
+			matched = append(matched, tv)
+		}
+	}
+	v.mu.RUnlock()
+
+	for _, tv := range matched {
+		if tv.MaxSize > 0 && len(msg.Data) > tv.MaxSize {
+			return v.reject(topic, msg, RejectReasonSize, fmt.Sprintf("%d bytes exceeds limit of %d", len(msg.Data), tv.MaxSize))
+		}
+		if tv.Senders != nil && msg.GetFrom() != self {
+			if allowed, reason := tv.Senders.AuthorizePeer(msg.GetFrom()); !allowed {
+				return v.reject(topic, msg, RejectReasonSender, reason)
+			}
+		}
+		if tv.Schema != nil {
+			if err := tv.Schema(msg); err != nil {
+				return v.reject(topic, msg, RejectReasonSchema, err.Error())
+			}
+		}
+	}
+	return pubsub.ValidationAccept
+}
+
+// reject records and logs a rejected message
+func (v *MessageValidator) reject(topic string, msg *pubsub.Message, reason, detail string) pubsub.ValidationResult {
+	v.mu.Lock()
+	v.rejected++
+	v.rejectedReasons[reason]++
+	logger := v.logger
+	v.mu.Unlock()
+
+	if logger != nil {
+		logger.Debugf("Rejected message on %s from %s (%s): %s", topic, msg.GetFrom(), reason, detail)
+	}
+	return pubsub.ValidationReject
+}
+
+// Stats returns a snapshot of the rejection counters
+func (v *MessageValidator) Stats() ValidatorStats {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	reasons := make(map[string]uint64, len(v.rejectedReasons))
+	for reason, count := range v.rejectedReasons {
+		reasons[reason] = count
+	}
+	return ValidatorStats{Rejected: v.rejected, RejectedReasons: reasons}
+}
+
+// PeerScoreConfig configures GossipSub peer scoring. Peers whose score falls
+// below the thresholds stop receiving gossip, have their publishes ignored and
+// are eventually graylisted.
+type PeerScoreConfig struct {
+	// Params holds the router-wide score parameters
+	Params *pubsub.PeerScoreParams
+	// Thresholds holds the score thresholds
+	Thresholds *pubsub.PeerScoreThresholds
+	// TopicParams, if set, returns the score parameters applied to a topic when it is
+	// joined (nil leaves the topic unscored). Topics listed in Params.Topics are scored
+	// from the start; TopicParams covers topics whose names are only known at runtime,
+	// such as event topics.
+	TopicParams func(topic string) *pubsub.TopicScoreParams
+	// Inspect, if set, receives the score of every connected peer each InspectInterval
+	Inspect func(scores map[peer.ID]float64)
+	// InspectInterval sets how often Inspect is called (defaults to one minute)
+	InspectInterval time.Duration
+}
+
+// DefaultPeerScoreConfig returns peer scoring that penalizes invalid messages,
+// protocol misbehaviour and many peers sharing one IP address
+func DefaultPeerScoreConfig() *PeerScoreConfig {
+	return &PeerScoreConfig{
+		Params: &pubsub.PeerScoreParams{
+			Topics:                      make(map[string]*pubsub.TopicScoreParams),
+			AppSpecificScore:            func(peer.ID) float64 { return 0 },
+			AppSpecificWeight:           1,
+			IPColocationFactorWeight:    -10,
+			IPColocationFactorThreshold: 10,
+			BehaviourPenaltyWeight:      -10,
+			BehaviourPenaltyThreshold:   6,
+			BehaviourPenaltyDecay:       pubsub.ScoreParameterDecay(10 * time.Minute),
+			DecayInterval:               pubsub.DefaultDecayInterval,
+			DecayToZero:                 pubsub.DefaultDecayToZero,
+			RetainScore:                 10 * time.Minute,
+		},
+		Thresholds: &pubsub.PeerScoreThresholds{
+			GossipThreshold:             -100,
+			PublishThreshold:            -500,
+			GraylistThreshold:           -1000,
+			AcceptPXThreshold:           10,
+			OpportunisticGraftThreshold: 5,
+		},
+		TopicParams: func(string) *pubsub.TopicScoreParams {
+			return DefaultTopicScoreParams()
+		},
+		InspectInterval: time.Minute,
+	}
+}
+
+// DefaultTopicScoreParams returns topic scoring that rewards first deliveries and
+// heavily penalizes messages rejected by validators
+func DefaultTopicScoreParams() *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		SkipAtomicValidation:           true,
+		TopicWeight:                    1,
+		FirstMessageDeliveriesWeight:   1,
+		FirstMessageDeliveriesDecay:    pubsub.ScoreParameterDecay(10 * time.Minute),
+		FirstMessageDeliveriesCap:      50,
+		InvalidMessageDeliveriesWeight: -100,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+	}
+}
+
+// pubsubOptions returns the GossipSub options for the configured validation and scoring
+func pubsubOptions(config *Libp2pConfig) []pubsub.Option {
+	opts := []pubsub.Option{
+		pubsub.WithMessageSigning(true),
+		pubsub.WithStrictSignatureVerification(true),
+	}
+
+	if config.MaxMessageSize > 0 {
+		opts = append(opts, pubsub.WithMaxMessageSize(config.MaxMessageSize))
+	}
+
+	if config.MessageValidator != nil {
+		opts = append(opts, pubsub.WithDefaultValidator(pubsub.ValidatorEx(config.MessageValidator.Validate)))
+	}
+
+	if score := config.PeerScore; score != nil {
+		opts = append(opts, pubsub.WithPeerScore(score.Params, score.Thresholds))
+		if score.Inspect != nil {
+			interval := score.InspectInterval
+			if interval <= 0 {
+				interval = time.Minute
+			}
+			opts = append(opts, pubsub.WithPeerScoreInspect(pubsub.PeerScoreInspectFn(score.Inspect), interval))
+		}
+	}
+
+	return opts
+}
+
+// JoinTopic joins a GossipSub topic and applies the topic score parameters from
+// score, which may be nil when peer scoring is disabled
+func JoinTopic(ps *pubsub.PubSub, name string, score *PeerScoreConfig) (*pubsub.Topic, error) {
+	topic, err := ps.Join(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join topic %s: %w", name, err)
+	}
+
+	if score == nil || score.TopicParams == nil {
+		return topic, nil
+	}
+	if score.Params != nil {
+		if _, ok := score.Params.Topics[name]; ok {
+			return topic, nil
+		}
+	}
+	if params := score.TopicParams(name); params != nil {
+		if err := topic.SetScoreParams(params); err != nil {
+			topic.Close()
+			return nil, fmt.Errorf("failed to set score parameters for topic %s: %w", name, err)
+		}
+	}
+	return topic, nil
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// testMessage builds a pubsub message as seen by a validator
+func testMessage(topic string, from peer.ID, data string) *pubsub.Message {
+	return &pubsub.Message{Message: &pb.Message{
+		Topic: &topic,
+		From:  []byte(from),
+		Data:  []byte(data),
+	}}
+}
+
+func TestMessageValidator(t *testing.T) {
+	self := testPeerID(t)
+	trusted := testPeerID(t)
+	stranger := testPeerID(t)
+
+	v, err := NewMessageValidator(
+		&TopicValidator{
+			Prefix:  "/o/",
+			MaxSize: 64,
+			Senders: PeerAuthorizerFunc(func(p peer.ID) (bool, string) {
+				return p == trusted, "not registered"
+			}),
+		},
+		&TopicValidator{
+			Topic:  "/o/tools/events/updated",
+			Schema: JSONObjectSchema("address", "event"),
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	v.setLocalPeer(self)
+
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		msg  *pubsub.Message
+		want pubsub.ValidationResult
+	}{
+		{"valid", testMessage("/o/tools/events/updated", trusted, `{"address":"o://tools","event":"updated"}`), pubsub.ValidationAccept},
+		{"local", testMessage("/o/tools/events/updated", self, `{"address":"o://tools","event":"updated"}`), pubsub.ValidationAccept},
+		{"unmatched topic", testMessage("chat", stranger, "anything goes here, however long it is"), pubsub.ValidationAccept},
+		{"too large", testMessage("/o/other", trusted, "this payload is a good deal longer than the sixty-four bytes allowed"), pubsub.ValidationReject},
+		{"unknown sender", testMessage("/o/other", stranger, "{}"), pubsub.ValidationReject},
+		{"missing field", testMessage("/o/tools/events/updated", trusted, `{"address":"o://tools"}`), pubsub.ValidationReject},
+		{"not json", testMessage("/o/tools/events/updated", trusted, "hello"), pubsub.ValidationReject},
+	}
+	for _, tc := range cases {
+		if got := v.Validate(ctx, trusted, tc.msg); got != tc.want {
+			t.Errorf("%s: expected result %d, got %d", tc.name, tc.want, got)
+		}
+	}
+
+	stats := v.Stats()
+	if stats.Rejected != 4 {
+		t.Errorf("Expected 4 rejections, got %d", stats.Rejected)
+	}
+	if stats.RejectedReasons[RejectReasonSize] != 1 || stats.RejectedReasons[RejectReasonSender] != 1 || stats.RejectedReasons[RejectReasonSchema] != 2 {
+		t.Errorf("Unexpected rejection reasons: %v", stats.RejectedReasons)
+	}
+}
+
+func TestMessageValidatorRejectsBadRules(t *testing.T) {
+	if _, err := NewMessageValidator(&TopicValidator{}); err == nil {
+		t.Error("Expected error for a validator without topic or prefix")
+	}
+	if _, err := NewMessageValidator(&TopicValidator{Topic: "a", Prefix: "b"}); err == nil {
+		t.Error("Expected error for a validator with both topic and prefix")
+	}
+	if _, err := NewMessageValidator(&TopicValidator{Topic: "a", MaxSize: -1}); err == nil {
+		t.Error("Expected error for a negative max size")
+	}
+}
+
+func TestCreateNodeWithValidationAndScoring(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	newConfig := func(validator *MessageValidator) *Libp2pConfig {
+		cfg := DefaultLibp2pConfig()
+		cfg.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+		cfg.EnableDHT = false
+		cfg.EnableRelay = false
+		cfg.MessageValidator = validator
+		cfg.PeerScore = DefaultPeerScoreConfig()
+		return cfg
+	}
+
+	validator, err := NewMessageValidator(&TopicValidator{Topic: "limited", MaxSize: 8})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	recvCfg := newConfig(validator)
+	receiver, _, recvPubSub, err := CreateNode(ctx, recvCfg)
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	defer receiver.Close()
+
+	sendCfg := newConfig(nil)
+	sender, _, sendPubSub, err := CreateNode(ctx, sendCfg)
+	if err != nil {
+		t.Fatalf("Failed to create sender: %v", err)
+	}
+	defer sender.Close()
+
+	if err := receiver.Connect(ctx, peer.AddrInfo{ID: sender.ID(), Addrs: sender.Addrs()}); err != nil {
+		t.Fatalf("Failed to connect nodes: %v", err)
+	}
+
+	recvTopic, err := JoinTopic(recvPubSub, "limited", recvCfg.PeerScore)
+	if err != nil {
+		t.Fatalf("Failed to join topic: %v", err)
+	}
+	sub, err := recvTopic.Subscribe()
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	sendTopic, err := JoinTopic(sendPubSub, "limited", sendCfg.PeerScore)
+	if err != nil {
+		t.Fatalf("Failed to join topic: %v", err)
+	}
+
+	// Publish an oversized message before each small one until a small one
+	// arrives; the oversized ones must never be delivered
+	for {
+		if err := sendTopic.Publish(ctx, []byte("far too large")); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		if err := sendTopic.Publish(ctx, []byte("ok")); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+
+		recvCtx, recvCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		msg, err := sub.Next(recvCtx)
+		recvCancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				t.Fatal("Timed out waiting for message")
+			}
+			continue
+		}
+		if string(msg.Data) != "ok" {
+			t.Fatalf("Expected only the small message to be delivered, got %q", msg.Data)
+		}
+		break
+	}
+
+	// Validation runs asynchronously, so the rejection may be counted after delivery
+	deadline := time.Now().Add(5 * time.Second)
+	for validator.Stats().RejectedReasons[RejectReasonSize] == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if validator.Stats().RejectedReasons[RejectReasonSize] == 0 {
+		t.Error("Expected oversized messages to be rejected")
+	}
+}
+
+func TestCreateNodeRequiresScoreParams(t *testing.T) {
+	cfg := DefaultLibp2pConfig()
+	cfg.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+	cfg.EnableDHT = false
+	cfg.EnableRelay = false
+	cfg.PeerScore = &PeerScoreConfig{}
+
+	if _, _, _, err := CreateNode(context.Background(), cfg); err == nil {
+		t.Error("Expected error for peer scoring without params")
+	}
+}
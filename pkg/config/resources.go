@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// ResourcePreset names a scaled set of resource manager limits
+type ResourcePreset string
+
+const (
+	// ResourcePresetSmall suits constrained tool nodes (256 MiB, 256 file descriptors)
+	ResourcePresetSmall ResourcePreset = "small"
+	// ResourcePresetMedium suits typical nodes (1 GiB, 1024 file descriptors)
+	ResourcePresetMedium ResourcePreset = "medium"
+	// ResourcePresetLarge suits leaders and relays (4 GiB, 8192 file descriptors)
+	ResourcePresetLarge ResourcePreset = "large"
+)
+
+const (
+	mib = 1 << 20
+	gib = 1 << 30
+)
+
+// presetScale returns the memory and file descriptor budget for a preset
+func presetScale(preset ResourcePreset) (int64, int, error) {
+	switch preset {
+	case ResourcePresetSmall:
+		return 256 * mib, 256, nil
+	case ResourcePresetMedium, "":
+		return 1 * gib, 1024, nil
+	case ResourcePresetLarge:
+		return 4 * gib, 8192, nil
+	default:
+		return 0, 0, fmt.Errorf("unknown resource preset %q", preset)
+	}
+}
+
+// ProtocolLimit bounds the resources used by the handlers of a single protocol.
+// Zero values keep the preset's default for that field.
+type ProtocolLimit struct {
+	// Streams limits the streams open on the protocol across all peers
+	Streams int
+	// StreamsPerPeer limits the streams open on the protocol with a single peer
+	StreamsPerPeer int
+	// Memory limits the memory reserved by the protocol across all peers
+	Memory int64
+	// MemoryPerPeer limits the memory reserved by the protocol for a single peer
+	MemoryPerPeer int64
+}
+
+// ResourceLimits configures the libp2p resource manager.
+// Zero values keep the preset's default for that field.
+type ResourceLimits struct {
+	// Preset selects the base limits (defaults to ResourcePresetMedium)
+	Preset ResourcePreset
+	// MaxMemory overrides the preset's total memory budget in bytes
+	MaxMemory int64
+	// MaxFileDescriptors overrides the preset's file descriptor budget
+	MaxFileDescriptors int
+	// MaxConnsPerPeer limits the connections to a single peer
+	MaxConnsPerPeer int
+	// MaxStreamsPerPeer limits the streams open with a single peer
+	MaxStreamsPerPeer int
+	// MaxMemoryPerPeer limits the memory reserved for a single peer
+	MaxMemoryPerPeer int64
+	// Protocols holds per-protocol limits, keyed by protocol ID (e.g. "/o/tools/calculator")
+	Protocols map[protocol.ID]ProtocolLimit
+}
+
+// NewResourceLimits returns resource limits for the given preset
+func NewResourceLimits(preset ResourcePreset) *ResourceLimits {
+	return &ResourceLimits{
+		Preset:    preset,
+		Protocols: make(map[protocol.ID]ProtocolLimit),
+	}
+}
+
+// SetProtocolLimit sets the limits for a protocol
+func (l *ResourceLimits) SetProtocolLimit(proto protocol.ID, limit ProtocolLimit) {
+	if l.Protocols == nil {
+		l.Protocols = make(map[protocol.ID]ProtocolLimit)
+	}
+	l.Protocols[proto] = limit
+}
+
+// Build computes the concrete limit configuration for the resource manager
+func (l *ResourceLimits) Build() (rcmgr.ConcreteLimitConfig, error) {
+	memory, fds, err := presetScale(l.Preset)
+	if err != nil {
+		return rcmgr.ConcreteLimitConfig{}, err
+	}
+	if l.MaxMemory > 0 {
+		memory = l.MaxMemory
+	}
+	if l.MaxFileDescriptors > 0 {
+		fds = l.MaxFileDescriptors
+	}
+
+	scaling := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&scaling)
+
+	partial := rcmgr.PartialLimitConfig{
+		PeerDefault: rcmgr.ResourceLimits{
+			Conns:   limitVal(l.MaxConnsPerPeer),
+			Streams: limitVal(l.MaxStreamsPerPeer),
+			Memory:  limitVal64(l.MaxMemoryPerPeer),
+		},
+	}
+
+	if len(l.Protocols) > 0 {
+		partial.Protocol = make(map[protocol.ID]rcmgr.ResourceLimits, len(l.Protocols))
+		partial.ProtocolPeer = make(map[protocol.ID]rcmgr.ResourceLimits, len(l.Protocols))
+		for proto, limit := range l.Protocols {
+			partial.Protocol[proto] = rcmgr.ResourceLimits{
+				Streams: limitVal(limit.Streams),
+				Memory:  limitVal64(limit.Memory),
+			}
+			partial.ProtocolPeer[proto] = rcmgr.ResourceLimits{
+				Streams: limitVal(limit.StreamsPerPeer),
+				Memory:  limitVal64(limit.MemoryPerPeer),
+			}
+		}
+	}
+
+	return partial.Build(scaling.Scale(memory, fds)), nil
+}
+
+// limitVal converts a zero-means-default limit to an rcmgr limit value
+func limitVal(n int) rcmgr.LimitVal {
+	if n <= 0 {
+		return rcmgr.DefaultLimit
+	}
+	return rcmgr.LimitVal(n)
+}
+
+// limitVal64 converts a zero-means-default limit to an rcmgr limit value
+func limitVal64(n int64) rcmgr.LimitVal64 {
+	if n <= 0 {
+		return rcmgr.DefaultLimit64
+	}
+	return rcmgr.LimitVal64(n)
+}
+
+// newResourceManager creates a resource manager enforcing the given limits.
+// Like libp2p's default resource manager, it reports its usage to the libp2p
+// Prometheus metrics.
+func newResourceManager(limits *ResourceLimits) (network.ResourceManager, error) {
+	concrete, err := limits.Build()
+	if err != nil {
+		return nil, err
+	}
+	reporter, err := rcmgr.NewStatsTraceReporter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource manager metrics reporter: %w", err)
+	}
+	return rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(concrete), rcmgr.WithTraceReporter(reporter))
+}
+
+// ResourceUsage returns the current resource usage of the host's resource manager
+func ResourceUsage(h host.Host) (*rcmgr.ResourceManagerStat, error) {
+	state, ok := h.Network().ResourceManager().(rcmgr.ResourceManagerState)
+	if !ok {
+		return nil, fmt.Errorf("resource manager does not expose usage statistics")
+	}
+	stat := state.Stat()
+	return &stat, nil
+}
@@ -2,24 +2,28 @@ package core
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
-	"github.com/multiformats/go-multihash"
 )
 
 // OAddress represents an o-protocol address (o://)
 type OAddress struct {
 	value      string
-	transports []interface{} // Can be multiaddr.Multiaddr or string
+	transports []Transport
 }
 
-// NewOAddress creates a new OAddress
+// NewOAddress creates a new OAddress. Transports may be Transports,
+// multiaddr.Multiaddrs or strings, which are classified by ParseTransport;
+// duplicates are dropped.
 func NewOAddress(value string, transports ...interface{}) *OAddress {
 	return &OAddress{
 		value:      value,
-		transports: transports,
+		transports: dedupTransports(transports),
 	}
 }
 
@@ -28,39 +32,70 @@ func (addr *OAddress) Value() string {
 	return addr.value
 }
 
+// Transports returns the transports of the address
+func (addr *OAddress) Transports() []Transport {
+	result := make([]Transport, len(addr.transports))
+	copy(result, addr.transports)
+	return result
+}
+
+// AddTransports appends transports the address does not already have
+func (addr *OAddress) AddTransports(transports ...Transport) {
+	values := make([]interface{}, 0, len(addr.transports)+len(transports))
+	for _, t := range addr.transports {
+		values = append(values, t)
+	}
+	for _, t := range transports {
+		values = append(values, t)
+	}
+	addr.transports = dedupTransports(values)
+}
+
 // SetTransports sets the transports for this address
 func (addr *OAddress) SetTransports(transports []multiaddr.Multiaddr) {
-	addr.transports = make([]interface{}, len(transports))
+	values := make([]interface{}, len(transports))
 	for i, t := range transports {
-		addr.transports[i] = t
+		values[i] = t
 	}
+	addr.transports = dedupTransports(values)
 }
 
-// SetTransportsFromStrings sets the transports from string values
+// SetTransportsFromStrings sets the transports from string values, classified by ParseTransport
 func (addr *OAddress) SetTransportsFromStrings(transports []string) {
-	addr.transports = make([]interface{}, len(transports))
+	values := make([]interface{}, len(transports))
 	for i, t := range transports {
-		addr.transports[i] = t
+		values[i] = t
 	}
+	addr.transports = dedupTransports(values)
 }
 
-// LibP2PTransports returns only the multiaddr.Multiaddr transports
+// LibP2PTransports returns only the libp2p transports
 func (addr *OAddress) LibP2PTransports() []multiaddr.Multiaddr {
 	var result []multiaddr.Multiaddr
 	for _, t := range addr.transports {
-		if ma, ok := t.(multiaddr.Multiaddr); ok {
-			result = append(result, ma)
+		if t.Kind() == TransportLibP2P {
+			result = append(result, t.Multiaddr())
 		}
 	}
 	return result
 }
 
-// CustomTransports returns only the string transports
+// CustomTransports returns only the custom transports
 func (addr *OAddress) CustomTransports() []string {
+	return addr.transportsOfKind(TransportCustom)
+}
+
+// InProcessTransports returns only the in-process transports
+func (addr *OAddress) InProcessTransports() []string {
+	return addr.transportsOfKind(TransportInProcess)
+}
+
+// transportsOfKind returns the transports of a kind as strings
+func (addr *OAddress) transportsOfKind(kind TransportKind) []string {
 	var result []string
 	for _, t := range addr.transports {
-		if s, ok := t.(string); ok {
-			result = append(result, s)
+		if t.Kind() == kind {
+			result = append(result, t.String())
 		}
 	}
 	return result
@@ -70,29 +105,129 @@ func (addr *OAddress) CustomTransports() []string {
 func (addr *OAddress) AllTransports() []string {
 	var result []string
 	for _, t := range addr.transports {
-		switch v := t.(type) {
-		case multiaddr.Multiaddr:
-			result = append(result, v.String())
-		case string:
-			result = append(result, v)
-		}
+		result = append(result, t.String())
 	}
 	return result
 }
 
+// PeerID returns the peer ID of the first libp2p transport that names one
+func (addr *OAddress) PeerID() (peer.ID, error) {
+	if len(addr.transports) == 0 {
+		return "", fmt.Errorf("address %s has no transports", addr.value)
+	}
+	var lastErr error
+	for _, t := range addr.transports {
+		id, err := t.PeerID()
+		if err == nil {
+			return id, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// withValue returns a copy of the address with a new value and the same transports
+func (addr *OAddress) withValue(value string) *OAddress {
+	return &OAddress{value: value, transports: addr.Transports()}
+}
+
 // Validate checks if the address is valid
 func (addr *OAddress) Validate() bool {
-	return strings.HasPrefix(addr.value, "o://")
+	return addr.Check() == nil
+}
+
+// Check returns an *AddressError describing why the address does not follow
+// the o-address grammar, or nil if it does
+func (addr *OAddress) Check() error {
+	_, err := parseAddress(addr.value, true)
+	return err
 }
 
-// Paths returns the path portion of the address (without o://)
+// Paths returns the path portion of the address (without o:// or the query)
 func (addr *OAddress) Paths() string {
-	return strings.TrimPrefix(addr.value, "o://")
+	return strings.TrimPrefix(addr.path(), "o://")
+}
+
+// path returns the address without its query or fragment
+func (addr *OAddress) path() string {
+	if i := strings.IndexAny(addr.value, "?#"); i >= 0 {
+		return addr.value[:i]
+	}
+	return addr.value
+}
+
+// RawQuery returns the encoded query of the address, without the leading ?
+func (addr *OAddress) RawQuery() string {
+	i := strings.IndexByte(addr.value, '?')
+	if i < 0 {
+		return ""
+	}
+	query := addr.value[i+1:]
+	if j := strings.IndexByte(query, '#'); j >= 0 {
+		query = query[:j]
+	}
+	return query
+}
+
+// Query returns the decoded query parameters of the address
+func (addr *OAddress) Query() url.Values {
+	values := url.Values{}
+	for _, param := range strings.Split(addr.RawQuery(), "&") {
+		if param == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(param, "=")
+		if k, err := url.PathUnescape(key); err == nil {
+			key = k
+		}
+		if v, err := url.PathUnescape(value); err == nil {
+			value = v
+		}
+		values.Add(key, value)
+	}
+	return values
+}
+
+// QueryParam returns the first value of a query parameter, such as the 2 of o://tools/calc?version=2
+func (addr *OAddress) QueryParam(key string) string {
+	return addr.Query().Get(key)
+}
+
+// WithQuery returns a copy of the address with a query parameter set, replacing any previous values
+func (addr *OAddress) WithQuery(key, value string) *OAddress {
+	query := addr.Query()
+	query.Set(key, value)
+	return addr.withValue(addr.path() + encodeQuery(query))
+}
+
+// WithoutQuery returns a copy of the address without its query
+func (addr *OAddress) WithoutQuery() *OAddress {
+	return addr.withValue(addr.path())
+}
+
+// encodeQuery encodes query parameters in normal form, including the leading ?
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, EscapeSegment(key)+"="+EscapeSegment(value))
+		}
+	}
+	return "?" + strings.Join(params, "&")
 }
 
 // Protocol returns the protocol form of the address (/o/...)
 func (addr *OAddress) Protocol() string {
-	return strings.Replace(addr.value, "o://", "/o/", 1)
+	return strings.Replace(addr.path(), "o://", "/o/", 1)
 }
 
 // Root returns the root portion of the address
@@ -126,48 +261,43 @@ func FromMultiaddr(ma multiaddr.Multiaddr) *OAddress {
 	return NewOAddress(value)
 }
 
-// Equals checks if two addresses are equal
+// Equals checks if two addresses are equal, comparing normal forms so that
+// o://a//b and o://a/b are the same address
 func (addr *OAddress) Equals(other *OAddress) bool {
-	return addr.value == other.value
+	if addr.value == other.value {
+		return true
+	}
+	a, err := parseAddress(addr.value, false)
+	if err != nil {
+		return false
+	}
+	b, err := parseAddress(other.value, false)
+	return err == nil && a == b
 }
 
-// ToCID generates a CID for this address
+// ToCID generates the DAG-JSON CID of {"address": value}, which nodes advertise in the DHT
 func (addr *OAddress) ToCID() (cid.Cid, error) {
-	// Convert to JSON-like bytes
-	jsonBytes := []byte(fmt.Sprintf(`{"address":"%s"}`, addr.String()))
-	
-	// Create a multihash
-	mh, err := multihash.Sum(jsonBytes, multihash.SHA2_256, -1)
-	if err != nil {
-		return cid.Undef, fmt.Errorf("failed to create multihash: %w", err)
-	}
-	
-	// Create CID v1 with DAG-JSON codec
-	c := cid.NewCidV1(cid.DagJSON, mh)
-	return c, nil
+	return ComputeCID(map[string]interface{}{"address": addr.String()})
 }
 
-// ParseOAddress parses a string into an OAddress
+// ParseOAddress parses a string into an OAddress in normal form, repairing
+// empty segments, fragments and unencoded characters. Errors are *AddressError.
 func ParseOAddress(value string) (*OAddress, error) {
-	if !strings.HasPrefix(value, "o://") {
-		return nil, fmt.Errorf("invalid o-address: must start with o://")
+	normalized, err := parseAddress(value, false)
+	if err != nil {
+		return nil, err
 	}
 	
-	return NewOAddress(value), nil
+	return NewOAddress(normalized), nil
 }
 
-// ChildAddress creates a child address under a parent
+// ChildAddress creates a child address under a parent, keeping the child's query
 func ChildAddress(parent, child *OAddress) *OAddress {
-	parentPath := parent.Paths()
-	childPath := child.Paths()
-	
-	// If parent already has a path, append the child
-	if parentPath != "" {
-		return NewOAddress(fmt.Sprintf("o://%s/%s", parentPath, childPath))
+	childPath := strings.Trim(child.Paths(), "/")
+	if query := child.RawQuery(); query != "" {
+		childPath += "?" + query
 	}
-	
-	// If parent is just a root, add the child path
-	return NewOAddress(fmt.Sprintf("%s/%s", parent.String(), childPath))
+	return parent.WithoutQuery().WithPath(childPath).WithTransports()
 }
 
 // SplitAddress splits an address into its components
@@ -184,14 +314,18 @@ func (addr *OAddress) HasPrefix(prefix string) bool {
 	return strings.HasPrefix(addr.value, prefix)
 }
 
-// WithPath appends a path to the address
+// WithPath appends a path to the address with exactly one slash between them,
+// keeping the address's query unless path brings its own
 func (addr *OAddress) WithPath(path string) *OAddress {
-	newValue := addr.value
-	if !strings.HasSuffix(newValue, "/") && !strings.HasPrefix(path, "/") {
-		newValue += "/"
+	path = strings.TrimLeft(path, "/")
+	base := strings.TrimRight(addr.path(), "/")
+	if path == "" {
+		return addr.Clone()
 	}
-	newValue += path
-	return NewOAddress(newValue, addr.transports...)
+	if query := addr.RawQuery(); query != "" && !strings.Contains(path, "?") {
+		path += "?" + query
+	}
+	return addr.withValue(base + "/" + path)
 }
 
 // WithTransports creates a copy of the address with new transports
@@ -201,12 +335,7 @@ func (addr *OAddress) WithTransports(transports ...interface{}) *OAddress {
 
 // Clone creates a copy of the address
 func (addr *OAddress) Clone() *OAddress {
-	newAddr := &OAddress{
-		value:      addr.value,
-		transports: make([]interface{}, len(addr.transports)),
-	}
-	copy(newAddr.transports, addr.transports)
-	return newAddr
+	return addr.withValue(addr.value)
 }
 
 // IsLeaderAddress checks if this is a leader address
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The o-address grammar, in ABNF (RFC 5234) with the URI rules of RFC 3986:
+//
+//	o-address   = "o://" segment *( "/" segment ) [ "?" query ]
+//	segment     = 1*( unreserved / pct-encoded )
+//	query       = param *( "&" param )
+//	param       = 1*qchar [ "=" *qchar ]
+//	qchar       = unreserved / pct-encoded
+//	unreserved  = ALPHA / DIGIT / "-" / "." / "_" / "~"
+//	pct-encoded = "%" HEXDIG HEXDIG
+//
+// Segments "." and ".." are not allowed, and addresses never carry fragments.
+// The normal form has a lowercase scheme, percent-encodes only bytes outside
+// unreserved with uppercase hex digits, and orders query parameters by key.
+
+// MaxAddressLength bounds the length of a parsed o-address in bytes
+const MaxAddressLength = 2048
+
+// addressScheme prefixes every o-address
+const addressScheme = "o://"
+
+// AddressError describes why a value is not a valid o-address
+type AddressError struct {
+	// Input is the value that failed to parse
+	Input string
+	// Offset is the byte offset of the problem in Input, or -1 if it has no position
+	Offset int
+	// Reason describes the problem
+	Reason string
+}
+
+// Error implements error
+func (e *AddressError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("invalid o-address %q: %s", e.Input, e.Reason)
+	}
+	return fmt.Sprintf("invalid o-address %q: %s at offset %d", e.Input, e.Reason, e.Offset)
+}
+
+// addressParser parses one value, repairing it unless strict
+type addressParser struct {
+	input  string
+	strict bool
+}
+
+// fail returns an AddressError at offset
+func (p *addressParser) fail(offset int, format string, args ...interface{}) error {
+	return &AddressError{Input: p.input, Offset: offset, Reason: fmt.Sprintf(format, args...)}
+}
+
+// parseAddress parses value into its normal form. Lenient parsing drops empty
+// segments and fragments, lowercases the scheme and percent-encodes characters
+// outside the grammar; strict parsing reports them as errors instead.
+func parseAddress(value string, strict bool) (string, error) {
+	p := &addressParser{input: value, strict: strict}
+	if len(value) > MaxAddressLength {
+		return "", p.fail(-1, "longer than %d bytes", MaxAddressLength)
+	}
+
+	if len(value) < len(addressScheme) || !strings.EqualFold(value[:len(addressScheme)], addressScheme) {
+		return "", p.fail(0, "must start with %s", addressScheme)
+	}
+	if strict && value[:len(addressScheme)] != addressScheme {
+		return "", p.fail(0, "scheme must be lowercase %s", addressScheme)
+	}
+	offset := len(addressScheme)
+	rest := value[offset:]
+
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		if strict {
+			return "", p.fail(offset+i, "fragments are not allowed")
+		}
+		rest = rest[:i]
+	}
+
+	path, query := rest, ""
+	hasQuery := false
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		path, query, hasQuery = rest[:i], rest[i+1:], true
+	}
+
+	var segments []string
+	start := offset
+	for _, raw := range strings.Split(path, "/") {
+		if raw == "" {
+			if strict {
+				return "", p.fail(start, "empty path segment")
+			}
+			start++
+			continue
+		}
+		segment, err := p.escape(raw, start)
+		if err != nil {
+			return "", err
+		}
+		if segment == "." || segment == ".." {
+			return "", p.fail(start, "dot segment %q is not allowed", raw)
+		}
+		segments = append(segments, segment)
+		start += len(raw) + 1
+	}
+	if len(segments) == 0 {
+		return "", p.fail(offset, "address has no path")
+	}
+
+	normalized := addressScheme + strings.Join(segments, "/")
+	if hasQuery {
+		params, err := p.parseQuery(query, offset+len(path)+1)
+		if err != nil {
+			return "", err
+		}
+		if len(params) > 0 {
+			normalized += "?" + strings.Join(params, "&")
+		}
+	}
+	return normalized, nil
+}
+
+// parseQuery normalizes the query parameters starting at offset, ordered by key
+func (p *addressParser) parseQuery(query string, offset int) ([]string, error) {
+	type param struct{ key, pair string }
+	var params []param
+
+	start := offset
+	for _, raw := range strings.Split(query, "&") {
+		if raw == "" {
+			if p.strict {
+				return nil, p.fail(start, "empty query parameter")
+			}
+			start++
+			continue
+		}
+
+		key, value, hasValue := raw, "", false
+		if i := strings.IndexByte(raw, '='); i >= 0 {
+			key, value, hasValue = raw[:i], raw[i+1:], true
+		}
+		if key == "" {
+			return nil, p.fail(start, "query parameter has no name")
+		}
+		k, err := p.escape(key, start)
+		if err != nil {
+			return nil, err
+		}
+		pair := k
+		if hasValue {
+			v, err := p.escape(value, start+len(key)+1)
+			if err != nil {
+				return nil, err
+			}
+			pair += "=" + v
+		}
+		params = append(params, param{key: k, pair: pair})
+		start += len(raw) + 1
+	}
+
+	sort.SliceStable(params, func(i, j int) bool { return params[i].key < params[j].key })
+	result := make([]string, len(params))
+	for i, param := range params {
+		result[i] = param.pair
+	}
+	return result, nil
+}
+
+// escape normalizes the percent-encoding of s, which starts at offset in the
+// input. Encoded unreserved bytes are decoded and other escapes uppercased;
+// lenient parsing encodes bytes outside the grammar, strict parsing rejects them.
+func (p *addressParser) escape(s string, offset int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			b.WriteByte(c)
+		case c == '%':
+			if i+2 >= len(s) {
+				return "", p.fail(offset+i, "incomplete percent-encoding")
+			}
+			hi, ok1 := unhex(s[i+1])
+			lo, ok2 := unhex(s[i+2])
+			if !ok1 || !ok2 {
+				return "", p.fail(offset+i, "malformed percent-encoding %q", s[i:i+3])
+			}
+			decoded := hi<<4 | lo
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", decoded)
+			}
+			i += 2
+		default:
+			if p.strict {
+				return "", p.fail(offset+i, "character %q must be percent-encoded", c)
+			}
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String(), nil
+}
+
+// isUnreserved reports whether c may appear unencoded in a segment or query
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// unhex decodes a hex digit
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// EscapeSegment percent-encodes every byte of s outside unreserved, so names
+// containing slashes or spaces can be used as a single segment or query value
+func EscapeSegment(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if isUnreserved(s[i]) {
+			b.WriteByte(s[i])
+		} else {
+			fmt.Fprintf(&b, "%%%02X", s[i])
+		}
+	}
+	return b.String()
+}
+
+// UnescapeSegment decodes a percent-encoded address segment
+func UnescapeSegment(s string) (string, error) {
+	return url.PathUnescape(s)
+}
+
+// ParseOAddressStrict parses value, rejecting anything that is not already a
+// valid o-address instead of repairing it. Percent-encoding is still normalized.
+func ParseOAddressStrict(value string) (*OAddress, error) {
+	normalized, err := parseAddress(value, true)
+	if err != nil {
+		return nil, err
+	}
+	return NewOAddress(normalized), nil
+}
+
+// NewOAddressStrict is NewOAddress for callers that want invalid values reported
+// rather than carried along until they fail to route
+func NewOAddressStrict(value string, transports ...interface{}) (*OAddress, error) {
+	address, err := ParseOAddressStrict(value)
+	if err != nil {
+		return nil, err
+	}
+	address.transports = dedupTransports(transports)
+	return address, nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/multiformats/go-multiaddr"
+)
+
+// addressJSON is the object form of an OAddress
+type addressJSON struct {
+	Value string `json:"value"`
+	// LibP2P holds the multiaddr transports
+	LibP2P []string `json:"libp2p,omitempty"`
+	// Custom holds the custom transports, such as http:// URLs
+	Custom []string `json:"custom,omitempty"`
+	// InProcess holds the in-process transports
+	InProcess []string `json:"inprocess,omitempty"`
+}
+
+// MarshalText implements encoding.TextMarshaler with the compact form, which is
+// the address value alone; transports are not included
+func (addr *OAddress) MarshalText() ([]byte, error) {
+	return []byte(addr.value), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, normalizing the address
+// and clearing any transports
+func (addr *OAddress) UnmarshalText(text []byte) error {
+	parsed, err := ParseOAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*addr = *parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Addresses without transports use the
+// compact form, a JSON string; others use the object form
+// {"value": ..., "libp2p": [...], "custom": [...], "inprocess": [...]}.
+func (addr *OAddress) MarshalJSON() ([]byte, error) {
+	if len(addr.transports) == 0 {
+		return json.Marshal(addr.value)
+	}
+	obj := addressJSON{Value: addr.value, Custom: addr.CustomTransports(), InProcess: addr.InProcessTransports()}
+	for _, ma := range addr.LibP2PTransports() {
+		obj.LibP2P = append(obj.LibP2P, ma.String())
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting both the compact and the object form
+func (addr *OAddress) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var value string
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return fmt.Errorf("failed to decode o-address: %w", err)
+		}
+		return addr.UnmarshalText([]byte(value))
+	}
+
+	var obj addressJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("failed to decode o-address: %w", err)
+	}
+	parsed, err := ParseOAddress(obj.Value)
+	if err != nil {
+		return err
+	}
+	transports := make([]Transport, 0, len(obj.LibP2P)+len(obj.Custom)+len(obj.InProcess))
+	for _, s := range obj.LibP2P {
+		ma, err := multiaddr.NewMultiaddr(s)
+		if err != nil {
+			return fmt.Errorf("failed to decode o-address transport %s: %w", s, err)
+		}
+		transports = append(transports, NewLibP2PTransport(ma))
+	}
+	// The object form records the kind, so custom transports are not reclassified
+	for _, s := range obj.Custom {
+		transports = append(transports, NewCustomTransport(s))
+	}
+	for _, s := range obj.InProcess {
+		transports = append(transports, NewInProcessTransport(s))
+	}
+	parsed.AddTransports(transports...)
+	*addr = *parsed
+	return nil
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestParseOAddress(t *testing.T) {
+	tests := []struct {
+		input    string
+		lenient  string // normal form, or "" if lenient parsing fails
+		strictOK bool
+	}{
+		{"o://leader", "o://leader", true},
+		{"o://leader/tools/calc", "o://leader/tools/calc", true},
+		{"o://tools/calc?version=2", "o://tools/calc?version=2", true},
+		{"o://tools/calc?version=2&region=eu", "o://tools/calc?region=eu&version=2", true},
+		{"o://my%20tool", "o://my%20tool", true},
+		{"o://my%2ftool", "o://my%2Ftool", true},
+		{"o://%7Euser", "o://~user", true},
+		{"o://leader//tools/", "o://leader/tools", false},
+		{"o:///leader", "o://leader", false},
+		{"O://leader", "o://leader", false},
+		{"o://leader/tools#calc", "o://leader/tools", false},
+		{"o://my tool", "o://my%20tool", false},
+		{"o://tools/calc?&version=2", "o://tools/calc?version=2", false},
+		{"o://tools/calc?", "o://tools/calc", false},
+		{"o://", "", false},
+		{"o:///", "", false},
+		{"leader/tools", "", false},
+		{"http://leader", "", false},
+		{"o://bad%2", "", false},
+		{"o://bad%zz", "", false},
+		{"o://leader/../tools", "", false},
+		{"o://leader/%2E", "", false},
+		{"o://tools?=2", "", false},
+		{"o://" + strings.Repeat("a", MaxAddressLength), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			address, err := ParseOAddress(tt.input)
+			if tt.lenient == "" {
+				var addrErr *AddressError
+				if !errors.As(err, &addrErr) {
+					t.Fatalf("Expected AddressError, got %v", err)
+				}
+				if addrErr.Input != tt.input || addrErr.Reason == "" {
+					t.Errorf("Expected descriptive error, got %+v", addrErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected %s, got error %v", tt.lenient, err)
+			} else if address.String() != tt.lenient {
+				t.Errorf("Expected %s, got %s", tt.lenient, address)
+			}
+
+			_, err = ParseOAddressStrict(tt.input)
+			if tt.strictOK && err != nil {
+				t.Errorf("Expected strict parse to succeed, got %v", err)
+			}
+			if !tt.strictOK && err == nil {
+				t.Error("Expected strict parse to fail")
+			}
+			if NewOAddress(tt.input).Validate() != tt.strictOK {
+				t.Errorf("Expected Validate to return %v", tt.strictOK)
+			}
+		})
+	}
+}
+
+func TestAddressErrorOffset(t *testing.T) {
+	_, err := ParseOAddressStrict("o://leader/to ols")
+	var addrErr *AddressError
+	if !errors.As(err, &addrErr) {
+		t.Fatalf("Expected AddressError, got %v", err)
+	}
+	if addrErr.Offset != 13 {
+		t.Errorf("Expected offset 13, got %d", addrErr.Offset)
+	}
+	if !strings.Contains(err.Error(), "must be percent-encoded") {
+		t.Errorf("Expected reason in error message, got %q", err.Error())
+	}
+
+	if _, err := NewOAddressStrict("o://leader//tools", "/ip4/127.0.0.1/tcp/4001"); err == nil {
+		t.Error("Expected NewOAddressStrict to reject an empty segment")
+	}
+	address, err := NewOAddressStrict("o://leader/tools", "/ip4/127.0.0.1/tcp/4001")
+	if err != nil || len(address.AllTransports()) != 1 {
+		t.Errorf("Expected address with transports, got %v (%v)", address, err)
+	}
+}
+
+func TestAddressQuery(t *testing.T) {
+	address := NewOAddress("o://tools/calc/add?version=2&name=a%26b")
+	if address.QueryParam("version") != "2" || address.QueryParam("name") != "a&b" {
+		t.Errorf("Expected decoded query params, got %v", address.Query())
+	}
+	if address.Paths() != "tools/calc/add" || address.Root() != "o://tools" || address.GetMethod() != "add" {
+		t.Errorf("Expected path accessors to ignore the query, got %s %s %s", address.Paths(), address.Root(), address.GetMethod())
+	}
+	if address.Protocol() != "/o/tools/calc/add" {
+		t.Errorf("Expected protocol without query, got %s", address.Protocol())
+	}
+	if got := address.WithQuery("version", "3").String(); got != "o://tools/calc/add?name=a%26b&version=3" {
+		t.Errorf("Expected replaced version, got %s", got)
+	}
+	if got := address.WithoutQuery().String(); got != "o://tools/calc/add" {
+		t.Errorf("Expected query to be dropped, got %s", got)
+	}
+	if !NewOAddress("o://tools//calc?b=1&a=2").Equals(NewOAddress("o://tools/calc?a=2&b=1")) {
+		t.Error("Expected addresses with the same normal form to be equal")
+	}
+}
+
+func TestAddressJoining(t *testing.T) {
+	tests := []struct {
+		base, path, expected string
+	}{
+		{"o://leader", "tools", "o://leader/tools"},
+		{"o://leader/", "/tools", "o://leader/tools"},
+		{"o://leader", "", "o://leader"},
+		{"o://tools/calc?version=2", "add", "o://tools/calc/add?version=2"},
+	}
+	for _, tt := range tests {
+		if got := NewOAddress(tt.base).WithPath(tt.path).String(); got != tt.expected {
+			t.Errorf("Expected %s.WithPath(%s) = %s, got %s", tt.base, tt.path, tt.expected, got)
+		}
+	}
+
+	child := ChildAddress(NewOAddress("o://network/"), NewOAddress("o://service"))
+	if child.String() != "o://network/service" {
+		t.Errorf("Expected o://network/service, got %s", child)
+	}
+	if name, _ := UnescapeSegment(EscapeSegment("a/b c&d")); name != "a/b c&d" {
+		t.Errorf("Expected segment to round-trip, got %q", name)
+	}
+	if _, err := ParseOAddressStrict("o://tools/" + EscapeSegment("a/b c&d")); err != nil {
+		t.Errorf("Expected escaped segment to be valid, got %v", err)
+	}
+}
+
+func FuzzParseOAddress(f *testing.F) {
+	for _, seed := range []string{
+		"o://leader", "o://tools/calc?version=2", "o://a//b/#frag", "O://x%2fy", "o://%zz", "o://a b?c=d&&e",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		address, err := ParseOAddress(input)
+		if err != nil {
+			var addrErr *AddressError
+			if !errors.As(err, &addrErr) {
+				t.Fatalf("Expected AddressError, got %T", err)
+			}
+			if _, err := ParseOAddressStrict(input); err == nil {
+				t.Fatalf("Strict parse accepted %q, which lenient parsing rejected", input)
+			}
+			return
+		}
+
+		// The normal form is valid, strict and a fixed point
+		normalized := address.String()
+		again, err := ParseOAddressStrict(normalized)
+		if err != nil {
+			t.Fatalf("Normal form %q of %q is not strict: %v", normalized, input, err)
+		}
+		if again.String() != normalized {
+			t.Fatalf("Normalization of %q is not idempotent: %q then %q", input, normalized, again)
+		}
+		if strict, err := ParseOAddressStrict(input); err == nil && strict.String() != normalized {
+			t.Fatalf("Strict and lenient parses of %q differ: %q and %q", input, strict, normalized)
+		}
+	})
+}
+
+func TestAddressJSON(t *testing.T) {
+	ma, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	if err != nil {
+		t.Fatalf("Failed to create multiaddr: %v", err)
+	}
+	dep := &ODependency{Address: NewOAddress("o://leader/tools/calc", ma, "http://localhost:8080"), Name: "calc"}
+
+	data, err := json.Marshal(dep)
+	if err != nil {
+		t.Fatalf("Failed to marshal dependency: %v", err)
+	}
+	expected := `{"address":{"value":"o://leader/tools/calc","libp2p":["/ip4/127.0.0.1/tcp/4001"],"custom":["http://localhost:8080"]},"name":"calc","description":"","optional":false}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	var decoded ODependency
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal dependency: %v", err)
+	}
+	if !decoded.Address.Equals(dep.Address) {
+		t.Errorf("Expected %s, got %s", dep.Address, decoded.Address)
+	}
+	if libp2p := decoded.Address.LibP2PTransports(); len(libp2p) != 1 || !libp2p[0].Equal(ma) {
+		t.Errorf("Expected multiaddr transport, got %v", libp2p)
+	}
+	if custom := decoded.Address.CustomTransports(); len(custom) != 1 || custom[0] != "http://localhost:8080" {
+		t.Errorf("Expected custom transport, got %v", custom)
+	}
+
+	// Addresses without transports use the compact form, which is normalized on decode
+	if data, _ := json.Marshal(NewOAddress("o://calc")); string(data) != `"o://calc"` {
+		t.Errorf("Expected compact form, got %s", data)
+	}
+	var compact OAddress
+	if err := json.Unmarshal([]byte(`"o://leader//calc"`), &compact); err != nil || compact.String() != "o://leader/calc" {
+		t.Errorf("Expected normalized o://leader/calc, got %s (%v)", compact.String(), err)
+	}
+	text, _ := NewOAddress("o://calc", ma).MarshalText()
+	if string(text) != "o://calc" {
+		t.Errorf("Expected text form without transports, got %s", text)
+	}
+
+	for _, invalid := range []string{`"calc"`, `{"value":"o://calc","libp2p":["not-a-multiaddr"]}`, `{"value":""}`, `42`} {
+		var address OAddress
+		if err := json.Unmarshal([]byte(invalid), &address); err == nil {
+			t.Errorf("Expected error decoding %s", invalid)
+		}
+	}
+}
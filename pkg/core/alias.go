@@ -0,0 +1,286 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// maxAliasHops bounds how many aliases TranslateAddress follows, so alias cycles cannot loop forever
+const maxAliasHops = 8
+
+// AliasTarget is one address an alias resolves to
+type AliasTarget struct {
+	Address string `json:"address"`
+	// Version labels the target, so callers can pin a version during a rolling upgrade
+	Version string `json:"version,omitempty"`
+	// Weight is the target's relative share of unpinned traffic; 0 drains it.
+	// RegisterAlias leaves an unset weight to the leader, which defaults it to 1.
+	Weight int `json:"weight"`
+}
+
+// Alias maps a static root address such as o://weather to one or more targets
+type Alias struct {
+	Name    string         `json:"name"`
+	Targets []*AliasTarget `json:"targets"`
+	// UpdatedAt is when the alias last changed, in Unix milliseconds
+	UpdatedAt int64 `json:"updatedAt"`
+}
+
+// clone returns a copy of the alias that shares no targets with it
+func (a *Alias) clone() *Alias {
+	c := *a
+	c.Targets = make([]*AliasTarget, len(a.Targets))
+	for i, t := range a.Targets {
+		target := *t
+		c.Targets[i] = &target
+	}
+	return &c
+}
+
+// validateAlias checks that name is a static root and target can be routed to
+func validateAlias(name string, target *AliasTarget) error {
+	address := NewOAddress(name)
+	if !address.HasPrefix("o://") || address.Root() != name || len(address.SplitAddress()) != 1 {
+		return fmt.Errorf("alias %s must be a root address such as o://weather", name)
+	}
+	if address.IsLeaderAddress() {
+		return fmt.Errorf("alias %s must not shadow the leader", name)
+	}
+	if !NewOAddress(target.Address).HasPrefix("o://") {
+		return fmt.Errorf("alias target %s must be an o:// address", target.Address)
+	}
+	if NewOAddress(target.Address).Root() == name {
+		return fmt.Errorf("alias %s must not target itself", name)
+	}
+	if target.Weight < 0 {
+		return fmt.Errorf("alias target weight must not be negative, got %d", target.Weight)
+	}
+	return nil
+}
+
+// SetAlias adds target to an alias, replacing the target with the same address
+func (r *Registry) SetAlias(name string, target *AliasTarget) error {
+	if err := validateAlias(name, target); err != nil {
+		return err
+	}
+	t := *target
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	alias, ok := r.aliases[name]
+	if !ok {
+		alias = &Alias{Name: name}
+		r.aliases[name] = alias
+	}
+	replaced := false
+	for i, existing := range alias.Targets {
+		if existing.Address == t.Address {
+			alias.Targets[i] = &t
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		alias.Targets = append(alias.Targets, &t)
+	}
+	alias.UpdatedAt = time.Now().UnixMilli()
+	r.changed()
+	r.notify(&RegistryChange{Alias: name})
+	return nil
+}
+
+// RemoveAlias removes the target with address from an alias, or the whole
+// alias if address is empty, reporting whether anything was removed
+func (r *Registry) RemoveAlias(name, address string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	alias, ok := r.aliases[name]
+	if !ok {
+		return false
+	}
+
+	if address == "" {
+		delete(r.aliases, name)
+		r.changed()
+		r.notify(&RegistryChange{Alias: name})
+		return true
+	}
+	for i, t := range alias.Targets {
+		if t.Address == address {
+			alias.Targets = append(alias.Targets[:i], alias.Targets[i+1:]...)
+			if len(alias.Targets) == 0 {
+				delete(r.aliases, name)
+			}
+			alias.UpdatedAt = time.Now().UnixMilli()
+			r.changed()
+			r.notify(&RegistryChange{Alias: name})
+			return true
+		}
+	}
+	return false
+}
+
+// Aliases returns every alias, ordered by name
+func (r *Registry) Aliases() []*Alias {
+	r.mu.RLock()
+	result := make([]*Alias, 0, len(r.aliases))
+	for _, alias := range r.aliases {
+		result = append(result, alias.clone())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// ResolveAlias picks a target of an alias. With a version, only targets of that
+// version are considered and weights only break ties between them; without one,
+// targets are chosen at random in proportion to their weights.
+func (r *Registry) ResolveAlias(name, version string) (*AliasTarget, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	alias, ok := r.aliases[name]
+	if !ok {
+		return nil, false
+	}
+
+	var candidates []*AliasTarget
+	total := 0
+	for _, t := range alias.Targets {
+		if version != "" && t.Version != version {
+			continue
+		}
+		if version == "" && t.Weight == 0 {
+			continue
+		}
+		candidates = append(candidates, t)
+		total += t.Weight
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	if total == 0 {
+		target := *candidates[rand.Intn(len(candidates))]
+		return &target, true
+	}
+
+	pick := rand.Intn(total)
+	for _, t := range candidates {
+		if pick < t.Weight {
+			target := *t
+			return &target, true
+		}
+		pick -= t.Weight
+	}
+	target := *candidates[len(candidates)-1]
+	return &target, true
+}
+
+// handleAliasRequest serves the alias methods of the leader registry
+func (n *CoreNode) handleAliasRequest(req *ORequest) *OResponse {
+	switch req.Method {
+	case "register_alias":
+		target := &AliasTarget{
+			Address: stringParam(req.Params, "address"),
+			Version: stringParam(req.Params, "version"),
+			Weight:  1,
+		}
+		if weight, ok := req.Params["weight"].(float64); ok {
+			target.Weight = int(weight)
+		}
+		alias := stringParam(req.Params, "alias")
+		if err := n.registry.SetAlias(alias, target); err != nil {
+			return NewOErrorResponse(req.ID, ErrorCodeInvalidAddress, err.Error(), nil)
+		}
+		n.logger.Debugf("Aliased %s to %s (version %q, weight %d)", alias, target.Address, target.Version, target.Weight)
+		return NewOResponse(req.ID, map[string]interface{}{"success": true})
+
+	case "delete_alias":
+		removed := n.registry.RemoveAlias(stringParam(req.Params, "alias"), stringParam(req.Params, "address"))
+		return NewOResponse(req.ID, map[string]interface{}{"success": removed})
+
+	default:
+		aliases := n.registry.Aliases()
+		if name := stringParam(req.Params, "alias"); name != "" {
+			filtered := make([]*Alias, 0, 1)
+			for _, alias := range aliases {
+				if alias.Name == name {
+					filtered = append(filtered, alias)
+				}
+			}
+			aliases = filtered
+		}
+		return NewOResponse(req.ID, map[string]interface{}{"data": aliases})
+	}
+}
+
+// RegisterAlias points alias at target on the leader. Registering an address the
+// alias already targets updates its version and weight. A zero weight is not sent,
+// so the target gets the leader's default of 1; use DrainAlias to drain a target.
+func (n *CoreNode) RegisterAlias(ctx context.Context, alias string, target *AliasTarget) error {
+	params := map[string]interface{}{
+		"alias":   alias,
+		"address": target.Address,
+		"version": target.Version,
+	}
+	if target.Weight != 0 {
+		params["weight"] = target.Weight
+	}
+	return n.callRegistry(ctx, "register_alias", params, nil)
+}
+
+// DrainAlias sets the weight of target to 0 on the leader, so it only receives
+// traffic pinned to its version
+func (n *CoreNode) DrainAlias(ctx context.Context, alias string, target *AliasTarget) error {
+	params := map[string]interface{}{
+		"alias":   alias,
+		"address": target.Address,
+		"version": target.Version,
+		"weight":  0,
+	}
+	return n.callRegistry(ctx, "register_alias", params, nil)
+}
+
+// DeleteAlias removes the target with address from alias on the leader, or the
+// whole alias if address is empty
+func (n *CoreNode) DeleteAlias(ctx context.Context, alias, address string) error {
+	return n.callRegistry(ctx, "delete_alias", map[string]interface{}{"alias": alias, "address": address}, nil)
+}
+
+// ListAliases returns the aliases registered with the leader
+func (n *CoreNode) ListAliases(ctx context.Context) ([]*Alias, error) {
+	var result struct {
+		Data []*Alias `json:"data"`
+	}
+	if err := n.callRegistry(ctx, "list_aliases", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// callRegistry calls a leader registry method, decoding its result into out if it is not nil
+func (n *CoreNode) callRegistry(ctx context.Context, method string, params map[string]interface{}, out interface{}) error {
+	response, err := n.Use(ctx, NewOAddress(RegistryStaticAddress), method, params, &UseOptions{NoIndex: true})
+	if err != nil {
+		return fmt.Errorf("failed to call registry %s: %w", method, err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if out == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(response.Result)
+	if err != nil {
+		return fmt.Errorf("failed to decode registry %s result: %w", method, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode registry %s result: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegistryAliasValidation(t *testing.T) {
+	registry := NewRegistry()
+	tests := []struct {
+		name   string
+		alias  string
+		target *AliasTarget
+	}{
+		{"nested alias", "o://weather/today", &AliasTarget{Address: "o://leader/weather"}},
+		{"leader alias", "o://leader", &AliasTarget{Address: "o://leader/weather"}},
+		{"non-o target", "o://weather", &AliasTarget{Address: "http://weather"}},
+		{"self target", "o://weather", &AliasTarget{Address: "o://weather/v2"}},
+		{"negative weight", "o://weather", &AliasTarget{Address: "o://leader/weather", Weight: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := registry.SetAlias(tt.alias, tt.target); err == nil {
+				t.Errorf("Expected error aliasing %s to %s", tt.alias, tt.target.Address)
+			}
+		})
+	}
+}
+
+func TestRegistryWeightedAliases(t *testing.T) {
+	registry := NewRegistry()
+	v1 := &AliasTarget{Address: "o://leader/services/weather/v1", Version: "1", Weight: 90}
+	v2 := &AliasTarget{Address: "o://leader/services/weather/v2", Version: "2", Weight: 10}
+	for _, target := range []*AliasTarget{v1, v2} {
+		if err := registry.SetAlias("o://weather", target); err != nil {
+			t.Fatalf("Failed to alias %s: %v", target.Address, err)
+		}
+	}
+
+	// Unpinned traffic splits by weight
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		target, ok := registry.ResolveAlias("o://weather", "")
+		if !ok {
+			t.Fatal("Expected alias to resolve")
+		}
+		counts[target.Version]++
+	}
+	if counts["2"] < 100 || counts["2"] > 300 {
+		t.Errorf("Expected about 10%% canary traffic, got %d of 2000", counts["2"])
+	}
+
+	// Pinned traffic only reaches its version, even when drained
+	v2.Weight = 0
+	registry.SetAlias("o://weather", v2)
+	for i := 0; i < 100; i++ {
+		if target, _ := registry.ResolveAlias("o://weather", "2"); target == nil || target.Address != v2.Address {
+			t.Fatalf("Expected pinned version 2, got %+v", target)
+		}
+		if target, _ := registry.ResolveAlias("o://weather", ""); target == nil || target.Address != v1.Address {
+			t.Fatalf("Expected drained version 2 to get no unpinned traffic, got %+v", target)
+		}
+	}
+	if _, ok := registry.ResolveAlias("o://weather", "3"); ok {
+		t.Error("Expected unknown version not to resolve")
+	}
+
+	// Aliases replicate with the registry
+	replica := NewRegistry()
+	replica.Restore(registry.Snapshot())
+	if aliases := replica.Aliases(); len(aliases) != 1 || len(aliases[0].Targets) != 2 {
+		t.Errorf("Expected replicated alias with 2 targets, got %v", aliases)
+	}
+
+	if !registry.RemoveAlias("o://weather", v1.Address) {
+		t.Error("Expected target to be removed")
+	}
+	if _, ok := registry.ResolveAlias("o://weather", ""); ok {
+		t.Error("Expected alias with only drained targets not to resolve")
+	}
+	if !registry.RemoveAlias("o://weather", "") || len(registry.Aliases()) != 0 {
+		t.Error("Expected alias to be removed")
+	}
+	if len(replica.Aliases()) != 1 {
+		t.Error("Expected replica to be independent of the original")
+	}
+}
+
+func TestAliasTranslation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	client := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	client.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer client.Stop(ctx)
+
+	// An unset weight gets the leader's default
+	target := &AliasTarget{Address: "o://leader/services/weather/v2", Version: "2"}
+	if err := client.RegisterAlias(ctx, "o://weather", target); err != nil {
+		t.Fatalf("Failed to register alias: %v", err)
+	}
+	// Aliases may point at other aliases
+	if err := client.RegisterAlias(ctx, "o://forecast", &AliasTarget{Address: "o://weather/forecast"}); err != nil {
+		t.Fatalf("Failed to register alias: %v", err)
+	}
+	if err := client.RegisterAlias(ctx, "o://leader", target); err == nil {
+		t.Error("Expected error aliasing the leader")
+	}
+
+	aliases, err := client.ListAliases(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list aliases: %v", err)
+	}
+	if len(aliases) != 2 || aliases[1].Name != "o://weather" || aliases[1].Targets[0].Version != "2" {
+		t.Fatalf("Expected both aliases, got %v", aliases)
+	}
+	if weight := aliases[1].Targets[0].Weight; weight != 1 {
+		t.Errorf("Expected default weight 1, got %d", weight)
+	}
+
+	for address, expected := range map[string]string{
+		"o://weather/forecast": "o://leader/services/weather/v2/forecast",
+		"o://forecast/today":   "o://leader/services/weather/v2/forecast/today",
+		// A version query pins the target and is not part of the routed address
+		"o://weather/forecast?version=2": "o://leader/services/weather/v2/forecast",
+		"o://weather/forecast?version=1": "o://weather/forecast",
+	} {
+		result, err := client.TranslateAddress(ctx, NewOAddress(address))
+		if err != nil {
+			t.Fatalf("Failed to translate %s: %v", address, err)
+		}
+		if result.TargetAddress.String() != expected {
+			t.Errorf("Expected %s to translate to %s, got %s", address, expected, result.TargetAddress)
+		}
+	}
+
+	// A drained target only serves requests pinned to its version
+	if err := client.DrainAlias(ctx, "o://weather", target); err != nil {
+		t.Fatalf("Failed to drain alias target: %v", err)
+	}
+	if drained, ok := leader.Registry().ResolveAlias("o://weather", ""); ok {
+		t.Errorf("Expected drained target not to take unpinned traffic, got %s", drained.Address)
+	}
+	if pinned, ok := leader.Registry().ResolveAlias("o://weather", "2"); !ok || pinned.Weight != 0 {
+		t.Errorf("Expected drained target to stay pinnable, got %+v", pinned)
+	}
+
+	if err := client.DeleteAlias(ctx, "o://weather", ""); err != nil {
+		t.Fatalf("Failed to delete alias: %v", err)
+	}
+	result, err := client.TranslateAddress(ctx, NewOAddress("o://weather/forecast"))
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	if result.TargetAddress.String() != "o://weather/forecast" {
+		t.Errorf("Expected deleted alias not to resolve, got %s", result.TargetAddress)
+	}
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegistryEvent is emitted by the leader on o://leader whenever a registration or alias changes
+const RegistryEvent = "registry"
+
+// RegistryChange is the payload of a RegistryEvent
+type RegistryChange struct {
+	// Address is the absolute address of the node that registered or left
+	Address string `json:"address,omitempty"`
+	// StaticAddress is the static address of that node
+	StaticAddress string `json:"staticAddress,omitempty"`
+	// Alias is the alias that changed
+	Alias string `json:"alias,omitempty"`
+}
+
+// ResolutionCacheConfig controls the client-side cache of static address resolutions
+type ResolutionCacheConfig struct {
+	// TTL is how long a resolved address is reused (negative disables the cache)
+	TTL time.Duration
+	// NegativeTTL is how long an address the leader could not resolve is left untranslated
+	NegativeTTL time.Duration
+	// MaxEntries bounds the cache; the entries closest to expiry are dropped first
+	MaxEntries int
+	// PushInvalidation subscribes to the leader's RegistryEvent to drop stale entries immediately
+	PushInvalidation bool
+}
+
+// DefaultResolutionCacheConfig returns the default resolution cache configuration
+func DefaultResolutionCacheConfig() *ResolutionCacheConfig {
+	return &ResolutionCacheConfig{
+		TTL:         30 * time.Second,
+		NegativeTTL: 5 * time.Second,
+		MaxEntries:  1024,
+	}
+}
+
+// cachedResolution is a cached static root; an empty address records that it did not resolve
+type cachedResolution struct {
+	address string
+	expires time.Time
+}
+
+// resolutionCache maps static roots to the absolute addresses the leader resolved them to.
+// A nil cache is disabled: lookups miss and updates are ignored.
+type resolutionCache struct {
+	config  *ResolutionCacheConfig
+	entries map[string]*cachedResolution
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// newResolutionCache creates a cache from cfg, or returns nil if cfg disables it
+func newResolutionCache(cfg *ResolutionCacheConfig) *resolutionCache {
+	defaults := DefaultResolutionCacheConfig()
+	if cfg == nil {
+		cfg = defaults
+	}
+	if cfg.TTL < 0 {
+		return nil
+	}
+	merged := *cfg
+	if merged.TTL == 0 {
+		merged.TTL = defaults.TTL
+	}
+	if merged.NegativeTTL <= 0 {
+		merged.NegativeTTL = defaults.NegativeTTL
+	}
+	if merged.MaxEntries <= 0 {
+		merged.MaxEntries = defaults.MaxEntries
+	}
+	return &resolutionCache{
+		config:  &merged,
+		entries: make(map[string]*cachedResolution),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached resolution of a root, which is empty if the root did not resolve
+func (c *resolutionCache) get(root string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[root]
+	if !ok {
+		return "", false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, root)
+		return "", false
+	}
+	return entry.address, true
+}
+
+// put caches the resolution of a root; an empty address caches a miss for NegativeTTL
+func (c *resolutionCache) put(root, address string) {
+	if c == nil {
+		return
+	}
+	ttl := c.config.TTL
+	if address == "" {
+		ttl = c.config.NegativeTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.entries[root]; !ok && len(c.entries) >= c.config.MaxEntries {
+		c.evict(now)
+	}
+	c.entries[root] = &cachedResolution{address: address, expires: now.Add(ttl)}
+}
+
+// evict drops expired entries, or the entry closest to expiry if none has expired;
+// callers hold the lock
+func (c *resolutionCache) evict(now time.Time) {
+	var oldest string
+	var oldestExpiry time.Time
+	for root, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, root)
+			continue
+		}
+		if oldest == "" || entry.expires.Before(oldestExpiry) {
+			oldest, oldestExpiry = root, entry.expires
+		}
+	}
+	if len(c.entries) >= c.config.MaxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// invalidate drops the cached resolution of a root
+func (c *resolutionCache) invalidate(root string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, root)
+}
+
+// invalidateResolved drops every resolution to address or an address beneath it
+func (c *resolutionCache) invalidateResolved(address string) {
+	if c == nil || address == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for root, entry := range c.entries {
+		if entry.address == address || strings.HasPrefix(entry.address, address+"/") {
+			delete(c.entries, root)
+		}
+	}
+}
+
+// apply drops the resolutions a registry change may have made stale
+func (c *resolutionCache) apply(change *RegistryChange) {
+	if change.StaticAddress != "" {
+		c.invalidate(NewOAddress(change.StaticAddress).Root())
+	}
+	if change.Alias != "" {
+		c.invalidate(change.Alias)
+	}
+	c.invalidateResolved(change.Address)
+}
+
+// publishRegistryChange emits a RegistryEvent so clients can drop stale resolutions
+func (n *CoreNode) publishRegistryChange(change *RegistryChange) {
+	if n.Host() == nil {
+		return
+	}
+	if err := n.Emit(n.context(), RegistryEvent, change); err != nil {
+		n.logger.Debugf("Failed to publish registry change: %v", err)
+	}
+}
+
+// subscribeRegistryChanges applies the leader's RegistryEvents to the resolution cache
+func (n *CoreNode) subscribeRegistryChanges() {
+	if n.resolutions == nil || !n.resolutions.config.PushInvalidation || n.Type() == NodeTypeLeader {
+		return
+	}
+	sub, err := n.On(NewOAddress("o://leader"), RegistryEvent, func(ctx context.Context, event *Event) {
+		var change RegistryChange
+		if err := event.Decode(&change); err != nil {
+			n.logger.Debugf("Dropped invalid registry change from %s: %v", event.PeerID, err)
+			return
+		}
+		n.resolutions.apply(&change)
+	})
+	if err != nil {
+		n.logger.Warnf("Failed to subscribe to registry changes: %v", err)
+		return
+	}
+	n.mu.Lock()
+	n.registryChanges = sub
+	n.mu.Unlock()
+}
+
+// unsubscribeRegistryChanges cancels the subscription made by subscribeRegistryChanges
+func (n *CoreNode) unsubscribeRegistryChanges() {
+	n.mu.Lock()
+	sub := n.registryChanges
+	n.registryChanges = nil
+	n.mu.Unlock()
+	if sub != nil {
+		sub.Cancel()
+	}
+}
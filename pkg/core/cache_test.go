@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestResolutionCache(t *testing.T) {
+	now := time.Now()
+	cache := newResolutionCache(&ResolutionCacheConfig{TTL: time.Minute, NegativeTTL: time.Second, MaxEntries: 3})
+	cache.now = func() time.Time { return now }
+
+	cache.put("o://calc", "o://leader/tools/calc")
+	cache.put("o://missing", "")
+	if address, ok := cache.get("o://calc"); !ok || address != "o://leader/tools/calc" {
+		t.Errorf("Expected cached resolution, got %q (%v)", address, ok)
+	}
+	if address, ok := cache.get("o://missing"); !ok || address != "" {
+		t.Errorf("Expected cached miss, got %q (%v)", address, ok)
+	}
+
+	// Misses expire sooner than resolutions
+	now = now.Add(2 * time.Second)
+	if _, ok := cache.get("o://missing"); ok {
+		t.Error("Expected cached miss to expire after NegativeTTL")
+	}
+	if _, ok := cache.get("o://calc"); !ok {
+		t.Error("Expected resolution to outlive NegativeTTL")
+	}
+
+	// A full cache drops the entry closest to expiry
+	cache.put("o://weather", "o://leader/services/weather")
+	cache.put("o://clock", "o://leader/tools/clock")
+	cache.put("o://search", "o://leader/tools/search")
+	if _, ok := cache.get("o://calc"); ok {
+		t.Error("Expected oldest entry to be evicted")
+	}
+	if _, ok := cache.get("o://search"); !ok {
+		t.Error("Expected newest entry to be cached")
+	}
+
+	// Registry changes drop the resolutions they affect
+	cache.apply(&RegistryChange{Address: "o://leader/services"})
+	if _, ok := cache.get("o://weather"); ok {
+		t.Error("Expected resolution beneath a removed address to be invalidated")
+	}
+	cache.apply(&RegistryChange{StaticAddress: "o://clock/v2"})
+	if _, ok := cache.get("o://clock"); ok {
+		t.Error("Expected resolution of a changed static address to be invalidated")
+	}
+	if _, ok := cache.get("o://search"); !ok {
+		t.Error("Expected unrelated resolution to stay cached")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("o://search"); ok {
+		t.Error("Expected resolution to expire after TTL")
+	}
+
+	var disabled *resolutionCache = newResolutionCache(&ResolutionCacheConfig{TTL: -1})
+	if disabled != nil {
+		t.Fatal("Expected a negative TTL to disable the cache")
+	}
+	disabled.put("o://calc", "o://leader/tools/calc")
+	if _, ok := disabled.get("o://calc"); ok {
+		t.Error("Expected a disabled cache to miss")
+	}
+}
+
+func TestUseCachesResolutions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+	leaderAddr := leaderAddress(leader.ID(), leader.Transports())
+
+	tool := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	tool.staticAddress = NewOAddress("o://calc")
+	tool.config.Leader = leaderAddr
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	client := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	client.config.Leader = leaderAddr
+	client.resolutions = newResolutionCache(&ResolutionCacheConfig{PushInvalidation: true})
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer client.Stop(ctx)
+
+	translate := func(address string, noIndex bool) string {
+		result, err := client.translate(ctx, NewOAddress(address), noIndex)
+		if err != nil {
+			t.Fatalf("Failed to translate %s: %v", address, err)
+		}
+		return result.TargetAddress.String()
+	}
+
+	if target := translate("o://calc/add", false); target != "o://leader/tools/calc/add" {
+		t.Fatalf("Expected o://leader/tools/calc/add, got %s", target)
+	}
+	if address, ok := client.resolutions.get("o://calc"); !ok || address != "o://leader/tools/calc" {
+		t.Errorf("Expected resolution to be cached, got %q (%v)", address, ok)
+	}
+	translate("o://missing", false)
+	if address, ok := client.resolutions.get("o://missing"); !ok || address != "" {
+		t.Errorf("Expected miss to be cached, got %q (%v)", address, ok)
+	}
+
+	// The cache answers until the leader pushes the removal; NoIndex always asks the leader
+	topics, err := leader.topicCache()
+	if err != nil {
+		t.Fatalf("Failed to get topics: %v", err)
+	}
+	events, err := topics.Join(EventTopic(NewOAddress("o://leader"), RegistryEvent))
+	if err != nil {
+		t.Fatalf("Failed to join registry events: %v", err)
+	}
+	waitUntil(t, ctx, "the client to subscribe to registry events", func() bool {
+		for _, p := range events.ListPeers() {
+			if p == client.ID() {
+				return true
+			}
+		}
+		return false
+	})
+	leader.Registry().Remove(tool.ID().String())
+	if target := translate("o://calc", true); target != "o://calc" {
+		t.Errorf("Expected NoIndex to bypass the cache, got %s", target)
+	}
+	waitUntil(t, ctx, "push invalidation", func() bool {
+		_, ok := client.resolutions.get("o://calc")
+		return !ok
+	})
+
+	// Failed connections drop the resolution that led to them
+	dead, _ := peer.Decode(testPeerID(t, "dead"))
+	client.setLeader(leaderAddress(dead, []string{fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", freePort(t))}))
+	client.resolutions.put("o://gone", "o://leader/tools/gone")
+	if _, err := client.Use(ctx, NewOAddress("o://gone"), "add", nil, nil); err == nil {
+		t.Fatal("Expected Use to fail when the leader is unreachable")
+	}
+	if _, ok := client.resolutions.get("o://gone"); ok {
+		t.Error("Expected resolution to be invalidated after a connection failure")
+	}
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multihash"
+)
+
+// IPLDCIDProvider derives CIDv1s from the canonical IPLD encoding of a value,
+// hashed with SHA2-256, as the IPLD codec specifications describe. Map keys are
+// sorted as the codec requires, so equal values always get the same CID.
+type IPLDCIDProvider struct {
+	// Codec is cid.DagJSON or cid.DagCBOR
+	Codec uint64
+}
+
+var _ CIDProvider = (*IPLDCIDProvider)(nil)
+
+// NewIPLDCIDProvider creates a CID provider for a codec, cid.DagJSON or cid.DagCBOR
+func NewIPLDCIDProvider(codec uint64) *IPLDCIDProvider {
+	return &IPLDCIDProvider{Codec: codec}
+}
+
+// Encode returns the canonical encoding of data. data may be any value that
+// encodes to JSON; []byte values encode as IPLD bytes and cid.Cid values as links.
+func (p *IPLDCIDProvider) Encode(data interface{}) ([]byte, error) {
+	node, err := toIPLDNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch p.Codec {
+	case cid.DagJSON:
+		err = dagjson.Encode(node, &buf)
+	case cid.DagCBOR:
+		err = dagcbor.Encode(node, &buf)
+	default:
+		return nil, fmt.Errorf("unsupported CID codec 0x%x", p.Codec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// CID returns the CIDv1 of data
+func (p *IPLDCIDProvider) CID(data interface{}) (cid.Cid, error) {
+	encoded, err := p.Encode(data)
+	if err != nil {
+		return cid.Undef, err
+	}
+	mh, err := multihash.Sum(encoded, multihash.SHA2_256, -1)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("failed to create multihash: %w", err)
+	}
+	return cid.NewCidV1(p.Codec, mh), nil
+}
+
+// ToCID returns the multihash of the CID of data, implementing CIDProvider
+func (p *IPLDCIDProvider) ToCID(data interface{}) (multihash.Multihash, error) {
+	c, err := p.CID(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Hash(), nil
+}
+
+// ComputeCID returns the DAG-JSON CID of data
+func ComputeCID(data interface{}) (cid.Cid, error) {
+	return NewIPLDCIDProvider(cid.DagJSON).CID(data)
+}
+
+// toIPLDNode converts a Go value to an IPLD node. Values other than the JSON
+// data model types, bytes and CIDs are converted through their JSON encoding.
+func toIPLDNode(data interface{}) (datamodel.Node, error) {
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := assembleIPLD(nb, data, 0); err != nil {
+		return nil, err
+	}
+	return nb.Build(), nil
+}
+
+// maxIPLDDepth bounds nesting so cyclic values fail instead of overflowing the stack
+const maxIPLDDepth = 256
+
+// assembleIPLD assembles a Go value into na
+func assembleIPLD(na datamodel.NodeAssembler, data interface{}, depth int) error {
+	if depth > maxIPLDDepth {
+		return fmt.Errorf("value nested deeper than %d levels", maxIPLDDepth)
+	}
+
+	switch v := data.(type) {
+	case nil:
+		return na.AssignNull()
+	case bool:
+		return na.AssignBool(v)
+	case string:
+		return na.AssignString(v)
+	case []byte:
+		return na.AssignBytes(v)
+	case int:
+		return na.AssignInt(int64(v))
+	case int32:
+		return na.AssignInt(int64(v))
+	case int64:
+		return na.AssignInt(v)
+	case uint32:
+		return na.AssignInt(int64(v))
+	case float32:
+		return assembleFloat(na, float64(v))
+	case float64:
+		return assembleFloat(na, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return na.AssignInt(i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %s: %w", v, err)
+		}
+		return assembleFloat(na, f)
+	case cid.Cid:
+		if !v.Defined() {
+			return fmt.Errorf("undefined CID")
+		}
+		return na.AssignLink(cidlink.Link{Cid: v})
+	case datamodel.Node:
+		return na.AssignNode(v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// The codecs sort keys themselves; sorting here keeps errors deterministic
+		sort.Strings(keys)
+		ma, err := na.BeginMap(int64(len(v)))
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := ma.AssembleKey().AssignString(key); err != nil {
+				return err
+			}
+			if err := assembleIPLD(ma.AssembleValue(), v[key], depth+1); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return ma.Finish()
+	case []interface{}:
+		la, err := na.BeginList(int64(len(v)))
+		if err != nil {
+			return err
+		}
+		for i, item := range v {
+			if err := assembleIPLD(la.AssembleValue(), item, depth+1); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return la.Finish()
+	}
+
+	// Anything else, such as structs with json tags, takes the shape of its JSON encoding
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to convert %T to IPLD: %w", data, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return fmt.Errorf("failed to convert %T to IPLD: %w", data, err)
+	}
+	return assembleIPLD(na, generic, depth+1)
+}
+
+// assembleFloat assembles a float, encoding integral values as integers as
+// JavaScript numbers do, and rejecting values IPLD cannot represent
+func assembleFloat(na datamodel.NodeAssembler, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%v cannot be encoded", f)
+	}
+	if f == math.Trunc(f) && math.Abs(f) <= 1<<53 {
+		return na.AssignInt(int64(f))
+	}
+	return na.AssignFloat(f)
+}
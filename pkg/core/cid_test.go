@@ -0,0 +1,123 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+)
+
+// cidVector is one entry of testdata/cid_vectors.json
+type cidVector struct {
+	Name       string          `json:"name"`
+	Value      json.RawMessage `json:"value"`
+	DagJSON    string          `json:"dagJson"`
+	DagCBOR    string          `json:"dagCbor"`
+	DagCBORHex string          `json:"dagCborHex"`
+}
+
+func loadCIDVectors(t *testing.T) []cidVector {
+	data, err := os.ReadFile("testdata/cid_vectors.json")
+	if err != nil {
+		t.Fatalf("Failed to read CID vectors: %v", err)
+	}
+	var doc struct {
+		Vectors []cidVector `json:"vectors"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to parse CID vectors: %v", err)
+	}
+	return doc.Vectors
+}
+
+func TestCIDVectors(t *testing.T) {
+	dagJSON := NewIPLDCIDProvider(cid.DagJSON)
+	dagCBOR := NewIPLDCIDProvider(cid.DagCBOR)
+
+	for _, v := range loadCIDVectors(t) {
+		t.Run(v.Name, func(t *testing.T) {
+			decoder := json.NewDecoder(bytes.NewReader(v.Value))
+			decoder.UseNumber()
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				t.Fatalf("Failed to decode value: %v", err)
+			}
+
+			if c, err := dagJSON.CID(value); err != nil || c.String() != v.DagJSON {
+				encoded, _ := dagJSON.Encode(value)
+				t.Errorf("Expected DAG-JSON CID %s, got %s (%v) for %s", v.DagJSON, c, err, encoded)
+			}
+			encoded, err := dagCBOR.Encode(value)
+			if err != nil || hex.EncodeToString(encoded) != v.DagCBORHex {
+				t.Errorf("Expected DAG-CBOR %s, got %x (%v)", v.DagCBORHex, encoded, err)
+			}
+			if c, err := dagCBOR.CID(value); err != nil || c.String() != v.DagCBOR {
+				t.Errorf("Expected DAG-CBOR CID %s, got %s (%v)", v.DagCBOR, c, err)
+			}
+
+			// Address vectors are the keys nodes advertise
+			if object, ok := value.(map[string]interface{}); ok && len(object) == 1 {
+				if address, ok := object["address"].(string); ok {
+					if c, err := NewOAddress(address).ToCID(); err != nil || c.String() != v.DagJSON {
+						t.Errorf("Expected address CID %s, got %s (%v)", v.DagJSON, c, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCIDProvider(t *testing.T) {
+	var provider CIDProvider = NewIPLDCIDProvider(cid.DagJSON)
+
+	// Go values hash like the JSON they encode to, whatever their type or key order
+	type registration struct {
+		Address string   `json:"address"`
+		Tags    []string `json:"tags"`
+		Weight  float64  `json:"weight"`
+	}
+	typed, err := provider.ToCID(&registration{Address: "o://calc", Tags: []string{"math"}, Weight: 2})
+	if err != nil {
+		t.Fatalf("Failed to compute CID: %v", err)
+	}
+	generic, err := provider.ToCID(map[string]interface{}{"weight": 2, "tags": []interface{}{"math"}, "address": "o://calc"})
+	if err != nil {
+		t.Fatalf("Failed to compute CID: %v", err)
+	}
+	if !bytes.Equal(typed, generic) {
+		t.Error("Expected struct and map with the same JSON to share a CID")
+	}
+
+	// Bytes and links use the IPLD kinds rather than JSON strings
+	link, _ := ComputeCID("o://leader")
+	encoded, err := NewIPLDCIDProvider(cid.DagJSON).Encode(map[string]interface{}{"data": []byte{1, 2, 3}, "link": link})
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := dagjson.Decode(nb, bytes.NewReader(encoded)); err != nil {
+		t.Fatalf("Failed to decode %s: %v", encoded, err)
+	}
+	node := nb.Build()
+	if data, _ := node.LookupByString("data"); data == nil || data.Kind().String() != "bytes" {
+		t.Errorf("Expected bytes, got %s", encoded)
+	}
+	if l, _ := node.LookupByString("link"); l == nil || l.Kind().String() != "link" {
+		t.Errorf("Expected link, got %s", encoded)
+	}
+
+	for _, invalid := range []interface{}{math.NaN(), math.Inf(1), make(chan int), cid.Undef} {
+		if _, err := ComputeCID(invalid); err == nil {
+			t.Errorf("Expected error for %v", invalid)
+		}
+	}
+	if _, err := NewIPLDCIDProvider(cid.Raw).CID("o://leader"); err == nil {
+		t.Error("Expected error for an unsupported codec")
+	}
+}
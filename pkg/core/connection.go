@@ -0,0 +1,339 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// Reserved request parameters added by the transport
+const (
+	// ParamTargetAddress carries the final o-address of a request
+	ParamTargetAddress = "_targetAddress"
+	// ParamCallerAddress carries the o-address of the calling node
+	ParamCallerAddress = "_callerAddress"
+)
+
+// requestDispatcher handles a request addressed to the local node
+type requestDispatcher func(ctx context.Context, req *ORequest) *OResponse
+
+// newRequestID generates a random request identifier
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to generate request ID: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// buildRequest creates the wire request for the given send parameters,
+// carrying the caller address and the trace context of ctx
+func buildRequest(ctx context.Context, params *ConnectionSendParams, caller *OAddress) *ORequest {
+	method, _ := params.Payload["method"].(string)
+	callParams, _ := params.Payload["params"].(map[string]interface{})
+
+	reqParams := make(map[string]interface{}, len(callParams)+2)
+	for k, v := range callParams {
+		reqParams[k] = v
+	}
+	reqParams[ParamTargetAddress] = params.Address
+	if caller != nil {
+		reqParams[ParamCallerAddress] = caller.String()
+	}
+	reqParams[ParamProtocolVersion] = ProtocolVersion
+	injectTraceContext(ctx, reqParams)
+
+	return NewORequest(newRequestID(), method, reqParams)
+}
+
+// isReservedParam reports whether a request parameter is set by the transport
+// rather than the caller
+func isReservedParam(name string) bool {
+	switch name {
+	case ParamTargetAddress, ParamCallerAddress, ParamProtocolVersion, ParamTraceContext,
+		ParamReplyTransports, ParamDeadline:
+		return true
+	}
+	return false
+}
+
+// userParams returns a copy of request parameters without the reserved ones
+func userParams(params map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if !isReservedParam(k) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// StreamConnection sends o-protocol requests over libp2p streams.
+// Each Send opens a new stream, writes one JSON request and reads one JSON response.
+type StreamConnection struct {
+	host       host.Host
+	peerID     peer.ID
+	remoteAddr multiaddr.Multiaddr
+	protocol   protocol.ID
+	caller     *OAddress
+	handshake  *Handshake
+}
+
+// Send implements Connection
+func (c *StreamConnection) Send(ctx context.Context, params *ConnectionSendParams) (*OResponse, error) {
+	s, err := c.host.NewStream(ctx, c.peerID, c.protocol)
+	if err != nil {
+		return nil, &undeliveredError{err: fmt.Errorf("failed to open stream to %s: %w", c.peerID, err)}
+	}
+	defer s.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetDeadline(deadline)
+	}
+
+	req := buildRequest(ctx, params, c.caller)
+	if err := json.NewEncoder(s).Encode(req); err != nil {
+		s.Reset()
+		return nil, fmt.Errorf("failed to write request: %w", err)
+	}
+	if err := s.CloseWrite(); err != nil {
+		s.Reset()
+		return nil, fmt.Errorf("failed to close request stream: %w", err)
+	}
+
+	var response OResponse
+	if err := json.NewDecoder(s).Decode(&response); err != nil {
+		s.Reset()
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// Close implements Connection. Streams are closed after every Send, so this is a no-op.
+func (c *StreamConnection) Close() error {
+	return nil
+}
+
+// RemotePeer implements Connection
+func (c *StreamConnection) RemotePeer() peer.ID {
+	return c.peerID
+}
+
+// RemoteAddr implements Connection
+func (c *StreamConnection) RemoteAddr() multiaddr.Multiaddr {
+	return c.remoteAddr
+}
+
+// Handshake implements Connection
+func (c *StreamConnection) Handshake() *Handshake {
+	return c.handshake
+}
+
+// localConnection dispatches requests to the local node without touching the network
+type localConnection struct {
+	peerID    peer.ID
+	caller    *OAddress
+	dispatch  requestDispatcher
+	handshake *Handshake
+}
+
+// Send implements Connection
+func (c *localConnection) Send(ctx context.Context, params *ConnectionSendParams) (*OResponse, error) {
+	return c.dispatch(ctx, buildRequest(ctx, params, c.caller)), nil
+}
+
+// Close implements Connection
+func (c *localConnection) Close() error {
+	return nil
+}
+
+// RemotePeer implements Connection
+func (c *localConnection) RemotePeer() peer.ID {
+	return c.peerID
+}
+
+// RemoteAddr implements Connection
+func (c *localConnection) RemoteAddr() multiaddr.Multiaddr {
+	return nil
+}
+
+// Handshake implements Connection
+func (c *localConnection) Handshake() *Handshake {
+	return c.handshake
+}
+
+// undeliveredError is a failure before a request was written to its peer, so
+// the peer cannot have run it and the request is safe to send again
+type undeliveredError struct {
+	err error
+}
+
+// Error implements error
+func (e *undeliveredError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *undeliveredError) Unwrap() error {
+	return e.err
+}
+
+// isUndelivered reports whether err happened before the request was sent
+func isUndelivered(err error) bool {
+	var undelivered *undeliveredError
+	return errors.As(err, &undelivered)
+}
+
+// StreamConnectionManager implements ConnectionManager on top of a libp2p host
+type StreamConnectionManager struct {
+	host        host.Host
+	logger      Logger
+	local       requestDispatcher
+	hello       func() *Hello
+	connections map[peer.ID]Connection
+	handshakes  map[peer.ID]*Handshake
+	mu          sync.RWMutex
+}
+
+// NewStreamConnectionManager creates a connection manager for the given host.
+// Requests addressed to the host itself are handed to local instead of being dialed.
+// The manager forgets a peer's connection and handshake when the host disconnects from it.
+func NewStreamConnectionManager(h host.Host, logger Logger, local requestDispatcher) *StreamConnectionManager {
+	if logger == nil {
+		logger = NewNoOpLogger()
+	}
+	m := &StreamConnectionManager{
+		host:        h,
+		logger:      logger,
+		local:       local,
+		connections: make(map[peer.ID]Connection),
+		handshakes:  make(map[peer.ID]*Handshake),
+	}
+	h.Network().Notify(&network.NotifyBundle{DisconnectedF: m.disconnected})
+	return m
+}
+
+// disconnected forgets a peer once its last connection closes, so reconnecting
+// to it repeats the handshake
+func (m *StreamConnectionManager) disconnected(net network.Network, c network.Conn) {
+	if net.Connectedness(c.RemotePeer()) == network.Connected {
+		return
+	}
+	m.mu.Lock()
+	delete(m.connections, c.RemotePeer())
+	delete(m.handshakes, c.RemotePeer())
+	m.mu.Unlock()
+}
+
+// Connect implements ConnectionManager
+func (m *StreamConnectionManager) Connect(ctx context.Context, params *ConnectionParams) (Connection, error) {
+	if params.NextHopAddress == nil {
+		return nil, fmt.Errorf("no next hop address")
+	}
+
+	transports := params.NextHopAddress.LibP2PTransports()
+	if len(transports) == 0 {
+		return nil, fmt.Errorf("no transports for %s", params.NextHopAddress.String())
+	}
+
+	infos, err := peer.AddrInfosFromP2pAddrs(transports...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transports for %s: %w", params.NextHopAddress.String(), err)
+	}
+	info := infos[0]
+
+	if info.ID == m.host.ID() {
+		if m.local == nil {
+			return nil, fmt.Errorf("Can not dial self")
+		}
+		// A node always agrees with itself
+		hello := m.localHello()
+		handshake := &Handshake{ProtocolVersion: hello.ProtocolVersion, Features: hello.Features, Remote: hello}
+		return &localConnection{peerID: info.ID, caller: params.CallerAddress, dispatch: m.local, handshake: handshake}, nil
+	}
+
+	firstConnection := m.host.Network().Connectedness(info.ID) != network.Connected
+	if err := m.host.Connect(ctx, info); err != nil {
+		return nil, err
+	}
+
+	// Peers exchange hellos on their first connection and reuse the result after
+	m.mu.RLock()
+	handshake, ok := m.handshakes[info.ID]
+	m.mu.RUnlock()
+	if firstConnection || !ok {
+		var err error
+		handshake, err = m.handshake(ctx, info.ID)
+		if err != nil {
+			var oErr *OError
+			if errors.As(err, &oErr) {
+				// Peers we cannot talk to are not kept connected
+				_ = m.host.Network().ClosePeer(info.ID)
+			}
+			return nil, err
+		}
+		m.mu.Lock()
+		m.handshakes[info.ID] = handshake
+		m.mu.Unlock()
+	}
+
+	var remoteAddr multiaddr.Multiaddr
+	if conns := m.host.Network().ConnsToPeer(info.ID); len(conns) > 0 {
+		remoteAddr = conns[0].RemoteMultiaddr()
+	}
+
+	conn := &StreamConnection{
+		host:       m.host,
+		peerID:     info.ID,
+		remoteAddr: remoteAddr,
+		protocol:   protocol.ID(params.NextHopAddress.Protocol()),
+		caller:     params.CallerAddress,
+		handshake:  handshake,
+	}
+
+	m.mu.Lock()
+	m.connections[info.ID] = conn
+	m.mu.Unlock()
+
+	return conn, nil
+}
+
+// Disconnect implements ConnectionManager
+func (m *StreamConnectionManager) Disconnect(peerID peer.ID) error {
+	m.mu.Lock()
+	delete(m.connections, peerID)
+	delete(m.handshakes, peerID)
+	m.mu.Unlock()
+
+	return m.host.Network().ClosePeer(peerID)
+}
+
+// GetConnection implements ConnectionManager
+func (m *StreamConnectionManager) GetConnection(peerID peer.ID) (Connection, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, ok := m.connections[peerID]
+	return conn, ok
+}
+
+// ListConnections implements ConnectionManager
+func (m *StreamConnectionManager) ListConnections() []Connection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]Connection, 0, len(m.connections))
+	for _, conn := range m.connections {
+		result = append(result, conn)
+	}
+	return result
+}
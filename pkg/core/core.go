@@ -35,6 +35,8 @@
 //		nil)
 package core
 
+import "fmt"
+
 const (
 	// Version is the current version of the core package
 	Version = "0.1.0"
@@ -56,6 +58,11 @@ const (
 	ErrorCodeTimeout           = 1005
 	ErrorCodeInvalidResponse   = 1006
 	ErrorCodeRegistrationFailed = 1007
+	ErrorCodeNotLeader          = 1008
+	ErrorCodeNotRegistered      = 1009
+	ErrorCodeIncompatibleProtocol = 1010
+	ErrorCodeNetworkMismatch      = 1011
+	ErrorCodeUnauthorized         = 1012
 )
 
 // NewOError creates a new OError with the given code and message
@@ -88,6 +95,39 @@ var (
 	ErrTimeout = func(operation string) *OError {
 		return NewOError(ErrorCodeTimeout, "operation timed out: "+operation, nil)
 	}
+
+	ErrNotLeader = func(leader string, transports []string) *OError {
+		return NewOError(ErrorCodeNotLeader, "not the leader", map[string]interface{}{
+			"leader":     leader,
+			"transports": transports,
+		})
+	}
+
+	ErrNotRegistered = func(peerID string) *OError {
+		return NewOError(ErrorCodeNotRegistered, "peer is not registered: "+peerID, nil)
+	}
+
+	ErrIncompatibleProtocol = func(version string) *OError {
+		return NewOError(ErrorCodeIncompatibleProtocol,
+			fmt.Sprintf("incompatible o-protocol version %s: this node speaks %s", version, ProtocolVersion),
+			map[string]interface{}{
+				"requested": version,
+				"supported": GetProtocolInfo(),
+			})
+	}
+
+	ErrUnauthorized = func(reason string) *OError {
+		return NewOError(ErrorCodeUnauthorized, "unauthorized: "+reason, nil)
+	}
+
+	ErrNetworkMismatch = func(remote, local string) *OError {
+		return NewOError(ErrorCodeNetworkMismatch,
+			fmt.Sprintf("peer is on network %s, not %s", remote, local),
+			map[string]interface{}{
+				"requested": remote,
+				"supported": local,
+			})
+	}
 )
 
 // ProtocolInfo contains information about the o-protocol
@@ -8,11 +8,18 @@ import (
 	"time"
 
 	"github.com/ipfs/go-cid"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
 	"github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/olane-labs/olane-go/pkg/config"
+	"github.com/olane-labs/olane-go/pkg/node"
+	"github.com/olane-labs/olane-go/pkg/utils"
 )
 
 // CoreNode is the base implementation of a node in the Olane network
@@ -32,6 +39,34 @@ type CoreNode struct {
 	description       string
 	dependencies      []*ODependency
 	methods           map[string]*OMethod
+	handlers          map[string]MethodHandler
+	metrics           *Metrics
+	tracer            trace.Tracer
+
+	// Network services (owned by the node once Initialize creates the host)
+	dht    *dht.IpfsDHT
+	pubsub *pubsub.PubSub
+	topics *node.TopicCache
+	mdns   mdns.Service
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Scatter-gather requests awaiting replies, by request ID
+	scatters map[string]*pendingScatter
+
+	// Hellos accepted from connected peers that dialed this node, by peer
+	hellos map[peer.ID]*Hello
+
+	// Leader registry and election (registry is only set on leaders)
+	registry *Registry
+	election *election
+
+	// Heartbeat or registry health check loop, while running
+	health *healthLoop
+
+	// Static address resolutions, and the leader's change events that invalidate them
+	resolutions     *resolutionCache
+	registryChanges *EventSubscription
 
 	// Statistics
 	successCount int64
@@ -57,8 +92,13 @@ func NewCoreNode(cfg *CoreConfig) *CoreNode {
 	}
 	loggerName = fmt.Sprintf("%s:%s", loggerName, cfg.Address.String())
 
+	logger := cfg.Logger
+	if logger == nil {
+		logger = NewLogger(loggerName)
+	}
+
 	node := &CoreNode{
-		logger:            NewLogger(loggerName),
+		logger:            logger,
 		address:           cfg.Address,
 		staticAddress:     cfg.Address.Clone(),
 		networkConfig:     cfg.Network,
@@ -68,6 +108,10 @@ func NewCoreNode(cfg *CoreConfig) *CoreNode {
 		description:       cfg.Description,
 		dependencies:      cfg.Dependencies,
 		methods:           cfg.Methods,
+		handlers:          make(map[string]MethodHandler),
+		scatters:          make(map[string]*pendingScatter),
+		hellos:            make(map[peer.ID]*Hello),
+		tracer:            newTracer(cfg.TracerProvider),
 		config:            cfg,
 		successCount:      0,
 		errorCount:        0,
@@ -77,6 +121,18 @@ func NewCoreNode(cfg *CoreConfig) *CoreNode {
 		node.networkConfig = config.DefaultLibp2pConfig()
 	}
 
+	if cfg.Metrics {
+		node.metrics = NewMetrics(node.connectedPeerCount, node.openConnectionCount)
+	}
+
+	if cfg.Type == NodeTypeLeader {
+		node.registry = NewRegistry()
+		node.registry.onSize = node.metrics.SetRegistrySize
+		node.registry.SetEmbedder(cfg.Embedder, nil)
+		node.registry.onChange = node.publishRegistryChange
+	}
+	node.resolutions = newResolutionCache(cfg.ResolutionCache)
+
 	if node.methods == nil {
 		node.methods = make(map[string]*OMethod)
 	}
@@ -106,8 +162,11 @@ func (n *CoreNode) State() NodeState {
 // setState sets the node state (thread-safe)
 func (n *CoreNode) setState(state NodeState) {
 	n.mu.Lock()
-	defer n.mu.Unlock()
+	previous := n.state
 	n.state = state
+	n.mu.Unlock()
+
+	n.metrics.observeStateTransition(previous, state)
 }
 
 // ID returns the peer ID of the node
@@ -122,6 +181,8 @@ func (n *CoreNode) Address() *OAddress {
 
 // Host returns the libp2p host
 func (n *CoreNode) Host() host.Host {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.p2pNode
 }
 
@@ -165,13 +226,60 @@ func (n *CoreNode) WhoAmI(ctx context.Context) (*WhoAmIResponse, error) {
 		Type:         n.Type(),
 		Description:  n.description,
 		Methods:      n.methods,
+		Tags:         n.config.Tags,
 		SuccessCount: n.successCount,
 		ErrorCount:   n.errorCount,
 		PeerID:       n.peerId.String(),
 		Transports:   n.Transports(),
+		Protocol:     GetProtocolInfo(),
 	}, nil
 }
 
+// Leader returns the leader address if configured or discovered
+func (n *CoreNode) Leader() *OAddress {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.config.Leader
+}
+
+// setLeader sets the leader address (thread-safe)
+func (n *CoreNode) setLeader(leader *OAddress) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.config.Leader = leader
+}
+
+// connectedPeerCount returns the number of connected peers
+func (n *CoreNode) connectedPeerCount() int {
+	if h := n.Host(); h != nil {
+		return len(h.Network().Peers())
+	}
+	return 0
+}
+
+// openConnectionCount returns the number of open connections
+func (n *CoreNode) openConnectionCount() int {
+	if h := n.Host(); h != nil {
+		return len(h.Network().Conns())
+	}
+	return 0
+}
+
+// Metrics returns the node's metrics, or nil if metrics are disabled
+func (n *CoreNode) Metrics() *Metrics {
+	return n.metrics
+}
+
+// context returns the node's lifetime context
+func (n *CoreNode) context() context.Context {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.ctx == nil {
+		return context.Background()
+	}
+	return n.ctx
+}
+
 // Parent returns the parent address if configured
 func (n *CoreNode) Parent() *OAddress {
 	return n.config.Parent
@@ -184,23 +292,11 @@ func (n *CoreNode) ParentPeerID() (peer.ID, error) {
 		return "", fmt.Errorf("no parent configured")
 	}
 
-	transports := parent.AllTransports()
-	if len(transports) == 0 {
-		return "", fmt.Errorf("no parent transports configured")
-	}
-
-	// Extract peer ID from multiaddr (format: /ip4/.../tcp/.../p2p/PeerID)
-	ma, err := multiaddr.NewMultiaddr(transports[0])
-	if err != nil {
-		return "", fmt.Errorf("invalid parent transport: %w", err)
-	}
-
-	peerIDComponent, err := ma.ValueForProtocol(multiaddr.P_P2P)
+	id, err := parent.PeerID()
 	if err != nil {
-		return "", fmt.Errorf("no peer ID in parent transport: %w", err)
+		return "", fmt.Errorf("no peer ID in parent transports: %w", err)
 	}
-
-	return peer.Decode(peerIDComponent)
+	return id, nil
 }
 
 // ParentTransports returns the parent's multiaddresses
@@ -210,15 +306,10 @@ func (n *CoreNode) ParentTransports() []multiaddr.Multiaddr {
 		return []multiaddr.Multiaddr{}
 	}
 
-	transports := parent.AllTransports()
-	result := make([]multiaddr.Multiaddr, 0, len(transports))
-	
-	for _, transport := range transports {
-		if ma, err := multiaddr.NewMultiaddr(transport); err == nil {
-			result = append(result, ma)
-		}
+	result := parent.LibP2PTransports()
+	if result == nil {
+		return []multiaddr.Multiaddr{}
 	}
-	
 	return result
 }
 
@@ -234,85 +325,138 @@ func (n *CoreNode) GetTransports(address *OAddress) []multiaddr.Multiaddr {
 	if len(leaderTransports) == 0 {
 		n.logger.Debug("No leader transports provided, searching within network")
 		
-		if n.config.Leader == nil {
+		leader := n.Leader()
+		if leader == nil {
 			if n.Type() == NodeTypeLeader {
 				n.logger.Debug("Node is a leader, using own transports")
-				transports := n.Transports()
-				result := make([]multiaddr.Multiaddr, 0, len(transports))
-				for _, transport := range transports {
-					if ma, err := multiaddr.NewMultiaddr(transport); err == nil {
-						result = append(result, ma)
-					}
+				if h := n.Host(); h != nil {
+					return p2pAddrs(peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()})
 				}
-				return result
+				return []multiaddr.Multiaddr{}
 			} else {
 				n.logger.Warn("Not within a network, cannot search for addressed node without leader")
 			}
 		} else {
-			leaderTransports = n.config.Leader.LibP2PTransports()
+			leaderTransports = leader.LibP2PTransports()
 		}
 	}
 
 	return leaderTransports
 }
 
-// HandleStaticAddressTranslation handles translation of static addresses
+// HandleStaticAddressTranslation handles translation of static addresses.
+// The leader may resolve a static root through an alias to another static
+// address, so translation repeats until the address is absolute.
 func (n *CoreNode) HandleStaticAddressTranslation(ctx context.Context, addressInput *OAddress) (*OAddress, error) {
+	return n.handleStaticAddressTranslation(ctx, addressInput, false), nil
+}
+
+// handleStaticAddressTranslation translates a static address through the
+// resolution cache, or always through the leader when noIndex is set. A version
+// query parameter pins the alias version and is dropped from the target.
+func (n *CoreNode) handleStaticAddressTranslation(ctx context.Context, addressInput *OAddress, noIndex bool) *OAddress {
 	result := addressInput
+	version := ""
+	if addressInput.RawQuery() != "" {
+		version = addressInput.QueryParam("version")
+		result = addressInput.WithoutQuery()
+	}
+	for hop := 0; hop < maxAliasHops && !result.HasPrefix("o://leader"); hop++ {
+		root := result.Root()
+		resolved, cached := "", false
+		// Pinned lookups bypass the cache, which only holds unpinned resolutions
+		useCache := !noIndex && version == ""
+		if useCache {
+			resolved, cached = n.resolutions.get(root)
+		}
+		if !cached {
+			var alias bool
+			var err error
+			resolved, alias, err = n.lookupStaticAddress(ctx, result, version)
+			if err != nil {
+				n.logger.Warnf("Failed to search for static address: %v", err)
+				break
+			}
+			// Aliases are resolved per call so weighted targets keep splitting traffic
+			if useCache && !alias {
+				n.resolutions.put(root, resolved)
+			}
+		}
+		if resolved == "" || resolved == root {
+			break
+		}
 
-	// Handle static address translation
-	if !addressInput.HasPrefix("o://leader") {
-		// Search for the static address in the leader registry
-		searchAddr := NewOAddress("o://leader/register")
-		searchAddr.SetTransports(result.LibP2PTransports())
+		// Add remainder paths to resolved address
+		parts := result.SplitAddress()
+		if len(parts) > 1 {
+			remainderPaths := parts[1:]
+			resolved = fmt.Sprintf("%s/%s", resolved, strings.Join(remainderPaths, "/"))
+		}
+		result = result.withValue(resolved)
+	}
+	return result
+}
 
-		response, err := n.Use(ctx, searchAddr, "search", map[string]interface{}{
-			"staticAddress": result.Root(),
-		}, &UseOptions{NoIndex: true})
+// lookupStaticAddress searches the leader registry for the root of a static
+// address, returning the address it resolves to ("" if none) and whether it
+// resolved through an alias. A non-empty version pins the alias target.
+func (n *CoreNode) lookupStaticAddress(ctx context.Context, address *OAddress, version string) (string, bool, error) {
+	// Search for the static address in the leader registry
+	searchAddr := NewOAddress("o://leader/register")
+	searchAddr.SetTransports(address.LibP2PTransports())
 
-		if err != nil {
-			n.logger.Warnf("Failed to search for static address: %v", err)
-			return result, nil
-		}
+	params := map[string]interface{}{
+		"staticAddress": address.Root(),
+	}
+	if version != "" {
+		params["version"] = version
+	}
+	response, err := n.Use(ctx, searchAddr, "search", params, &UseOptions{NoIndex: true})
+	if err != nil {
+		return "", false, err
+	}
 
-		// Process search results
-		if response.Result != nil {
-			if searchResults, ok := response.Result.(map[string]interface{})["data"].([]interface{}); ok && len(searchResults) > 0 {
-				if firstResult, ok := searchResults[0].(map[string]interface{}); ok {
-					if resolvedAddr, ok := firstResult["address"].(string); ok {
-						// Add remainder paths to resolved address
-						parts := addressInput.SplitAddress()
-						if len(parts) > 1 {
-							remainderPaths := parts[1:]
-							resolvedAddr = fmt.Sprintf("%s/%s", resolvedAddr, strings.Join(remainderPaths, "/"))
-						}
-						// Convert string slice to interface slice
-						transports := make([]interface{}, len(result.AllTransports()))
-						for i, t := range result.AllTransports() {
-							transports[i] = t
-						}
-						result = NewOAddress(resolvedAddr, transports...)
-					}
+	// Process search results
+	if response.Result != nil {
+		if searchResults, ok := response.Result.(map[string]interface{})["data"].([]interface{}); ok && len(searchResults) > 0 {
+			if firstResult, ok := searchResults[0].(map[string]interface{}); ok {
+				if resolvedAddr, ok := firstResult["address"].(string); ok {
+					_, alias := firstResult["alias"]
+					return resolvedAddr, alias, nil
 				}
-			} else {
-				n.logger.Warn("Failed to translate static address - no results found")
 			}
+		} else {
+			n.logger.Warn("Failed to translate static address - no results found")
 		}
 	}
 
-	return result, nil
+	return "", false, nil
 }
 
 // TranslateAddress translates an address to determine next hop and target
-func (n *CoreNode) TranslateAddress(ctx context.Context, addressWithLeaderTransports *OAddress) (*TranslateAddressResult, error) {
-	targetAddress := addressWithLeaderTransports
-	
+func (n *CoreNode) TranslateAddress(ctx context.Context, address *OAddress) (*TranslateAddressResult, error) {
+	return n.translate(ctx, address, false)
+}
+
+// translate traces the translation of an address, bypassing the resolution cache when noIndex is set
+func (n *CoreNode) translate(ctx context.Context, address *OAddress, noIndex bool) (*TranslateAddressResult, error) {
+	ctx, span := n.tracer.Start(ctx, "olane.TranslateAddress",
+		trace.WithAttributes(attrAddress.String(address.String())))
+	result, err := n.translateAddress(ctx, address, noIndex)
+	if result != nil {
+		span.SetAttributes(
+			attrNextHop.String(result.NextHopAddress.String()),
+			attrTarget.String(result.TargetAddress.String()),
+		)
+	}
+	endSpan(span, nil, err)
+	return result, err
+}
+
+// translateAddress resolves the next hop and target for an address
+func (n *CoreNode) translateAddress(ctx context.Context, addressWithLeaderTransports *OAddress, noIndex bool) (*TranslateAddressResult, error) {
 	// Handle static address translation
-	var err error
-	targetAddress, err = n.HandleStaticAddressTranslation(ctx, targetAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed to handle static address translation: %w", err)
-	}
+	targetAddress := n.handleStaticAddressTranslation(ctx, addressWithLeaderTransports, noIndex)
 
 	// Resolve the next hop address
 	nextHopAddress, err := n.addressResolution.Resolve(ctx, targetAddress)
@@ -332,12 +476,41 @@ func (n *CoreNode) TranslateAddress(ctx context.Context, addressWithLeaderTransp
 
 // Use executes a method on a remote address
 func (n *CoreNode) Use(ctx context.Context, address *OAddress, method string, params map[string]interface{}, opts *UseOptions) (*OResponse, error) {
+	ctx, span := n.tracer.Start(ctx, "olane.Use",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrAddress.String(address.String()), attrMethod.String(method)))
+	start := time.Now()
+	response, err := n.use(ctx, address, method, params, opts)
+	n.metrics.observeUse(address.String(), method, response, err, time.Since(start))
+	endSpan(span, response, err)
+	return response, err
+}
+
+// use sends the request and, if a call routed through the leader fails because
+// the leader changed, retries it once through the current leader.
+// Translation sets transports on the address it resolves, so each attempt
+// works on a copy and the caller's address is left as it was.
+func (n *CoreNode) use(ctx context.Context, address *OAddress, method string, params map[string]interface{}, opts *UseOptions) (*OResponse, error) {
+	explicit := len(address.LibP2PTransports()) > 0
+	response, err := n.call(ctx, address.Clone(), method, params, opts)
+	if !n.shouldRetarget(explicit, response, err) {
+		return response, err
+	}
+	if rerr := n.retargetLeader(ctx); rerr != nil {
+		n.logger.Debugf("Failed to retarget leader: %v", rerr)
+		return response, err
+	}
+	return n.call(ctx, address.Clone(), method, params, opts)
+}
+
+// call translates the address, connects to the next hop and sends the request
+func (n *CoreNode) call(ctx context.Context, address *OAddress, method string, params map[string]interface{}, opts *UseOptions) (*OResponse, error) {
 	if opts == nil {
 		opts = DefaultUseOptions()
 	}
 
 	// Translate the address
-	result, err := n.TranslateAddress(ctx, address)
+	result, err := n.translate(ctx, address, opts.NoIndex)
 	if err != nil {
 		n.incrementErrorCount()
 		return nil, fmt.Errorf("failed to translate address: %w", err)
@@ -347,7 +520,8 @@ func (n *CoreNode) Use(ctx context.Context, address *OAddress, method string, pa
 	connection, err := n.Connect(ctx, result.NextHopAddress, result.TargetAddress)
 	if err != nil {
 		n.incrementErrorCount()
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		n.resolutions.invalidate(address.Root())
+		return nil, &undeliveredError{err: fmt.Errorf("failed to connect: %w", err)}
 	}
 	defer connection.Close()
 
@@ -363,6 +537,7 @@ func (n *CoreNode) Use(ctx context.Context, address *OAddress, method string, pa
 	response, err := connection.Send(ctx, sendParams)
 	if err != nil {
 		n.incrementErrorCount()
+		n.resolutions.invalidate(address.Root())
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
@@ -372,6 +547,18 @@ func (n *CoreNode) Use(ctx context.Context, address *OAddress, method string, pa
 
 // Connect establishes a connection to a target through a next hop
 func (n *CoreNode) Connect(ctx context.Context, nextHopAddress, targetAddress *OAddress) (Connection, error) {
+	ctx, span := n.tracer.Start(ctx, "olane.Connect",
+		trace.WithAttributes(attrNextHop.String(nextHopAddress.String()), attrTarget.String(targetAddress.String())))
+	connection, err := n.connect(ctx, nextHopAddress, targetAddress)
+	if connection != nil {
+		span.SetAttributes(attrRemotePeer.String(connection.RemotePeer().String()))
+	}
+	endSpan(span, nil, err)
+	return connection, err
+}
+
+// connect opens a connection to the next hop through the connection manager
+func (n *CoreNode) connect(ctx context.Context, nextHopAddress, targetAddress *OAddress) (Connection, error) {
 	if n.connectionManager == nil {
 		return nil, fmt.Errorf("connection manager not initialized")
 	}
@@ -399,22 +586,38 @@ func (n *CoreNode) AdvertiseValueToNetwork(ctx context.Context, value cid.Cid) e
 	if n.p2pNode == nil {
 		return fmt.Errorf("p2p node not initialized")
 	}
+	if n.dht == nil {
+		return fmt.Errorf("DHT is not enabled on this node")
+	}
 
-	// For now, we'll simulate the advertisement
-	// In a real implementation, this would use the DHT service
 	n.logger.Debugf("Advertising CID to network: %s", value.String())
 	
 	// Create a timeout context
 	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// Simulate provide operation (in real implementation, this would use DHT)
-	select {
-	case <-timeoutCtx.Done():
-		return fmt.Errorf("advertise timeout")
-	case <-time.After(100 * time.Millisecond): // Simulate network operation
-		return nil
+	start := time.Now()
+	err := n.dht.Provide(timeoutCtx, value, true)
+	n.metrics.observeDHT("provide", err, time.Since(start))
+	if err != nil {
+		return fmt.Errorf("failed to provide %s: %w", value.String(), err)
 	}
+	return nil
+}
+
+// FindProviders looks up the peers advertising a CID in the DHT
+func (n *CoreNode) FindProviders(ctx context.Context, value cid.Cid) ([]peer.AddrInfo, error) {
+	if n.dht == nil {
+		return nil, fmt.Errorf("DHT is not enabled on this node")
+	}
+
+	start := time.Now()
+	providers, err := n.dht.FindProviders(ctx, value)
+	n.metrics.observeDHT("find", err, time.Since(start))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find providers for %s: %w", value.String(), err)
+	}
+	return providers, nil
 }
 
 // AdvertiseToNetwork advertises this node's addresses to the network
@@ -457,27 +660,45 @@ func (n *CoreNode) Register(ctx context.Context) error {
 
 	n.logger.Debug("Registering node...")
 
-	if n.config.Leader == nil {
+	if n.Leader() == nil {
 		n.logger.Warn("No leader configured, skipping registration")
 		return nil
 	}
 
 	address := NewOAddress("o://register")
-	params := map[string]interface{}{
+	_, err := n.Use(ctx, address, "commit", n.registrationParams(), &UseOptions{NoIndex: true})
+	if err != nil {
+		return fmt.Errorf("failed to register with leader: %w", err)
+	}
+
+	n.logger.Debug("Successfully registered with leader")
+	return nil
+}
+
+// registrationParams returns the payload Register commits to the leader registry
+func (n *CoreNode) registrationParams() map[string]interface{} {
+	return map[string]interface{}{
 		"peerId":        n.peerId.String(),
 		"address":       n.address.String(),
 		"protocols":     []string{}, // Would be populated from p2pNode.GetProtocols()
 		"transports":    n.Transports(),
 		"staticAddress": n.staticAddress.String(),
+		"type":          n.Type(),
+		"description":   n.description,
+		"methods":       n.registeredMethods(),
+		"tags":          n.config.Tags,
 	}
+}
 
-	_, err := n.Use(ctx, address, "commit", params, &UseOptions{NoIndex: true})
-	if err != nil {
-		return fmt.Errorf("failed to register with leader: %w", err)
+// registeredMethods returns a copy of the node's method descriptions
+func (n *CoreNode) registeredMethods() map[string]*OMethod {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	methods := make(map[string]*OMethod, len(n.methods))
+	for name, method := range n.methods {
+		methods[name] = method
 	}
-
-	n.logger.Debug("Successfully registered with leader")
-	return nil
+	return methods
 }
 
 // Unregister removes this node from the network
@@ -487,6 +708,11 @@ func (n *CoreNode) Unregister(ctx context.Context) error {
 		return nil
 	}
 
+	if n.Leader() == nil {
+		n.logger.Debug("No leader configured, skipping unregistration")
+		return nil
+	}
+
 	address := NewOAddress("o://register")
 	params := map[string]interface{}{
 		"peerId": n.peerId.String(),
@@ -516,12 +742,96 @@ func (n *CoreNode) incrementErrorCount() {
 	n.errorCount++
 }
 
-// Initialize performs node initialization (to be overridden by concrete implementations)
+// Initialize performs node initialization (to be overridden by concrete implementations).
+// It creates the libp2p host from the network configuration, installs the
+// o-protocol stream handlers and starts local discovery if enabled.
 func (n *CoreNode) Initialize(ctx context.Context) error {
 	n.logger.Debug("Initializing core node...")
+
+	if n.p2pNode != nil {
+		return nil
+	}
+
+	nodeCtx, cancel := context.WithCancel(ctx)
+
+	// Route libp2p setup output to the node's logger unless one was configured
+	networkConfig := *n.networkConfig
+	if networkConfig.Logger == nil {
+		networkConfig.Logger = n.logger
+	}
+	if n.metrics != nil && networkConfig.PrometheusRegisterer == nil {
+		networkConfig.PrometheusRegisterer = n.metrics.Registry()
+	}
+	if n.config.Seed != "" {
+		identity, err := utils.DeriveEd25519Key(n.config.Seed)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed to derive identity from seed: %w", err)
+		}
+		networkConfig.Identity = identity
+	}
+
+	h, kadDHT, gossipSub, err := config.CreateNode(nodeCtx, &networkConfig)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create libp2p node: %w", err)
+	}
+
+	if n.config.Election != nil {
+		n.election, err = newElection(n, n.config.Election, h.Peerstore(), h.ID())
+		if err != nil {
+			h.Close()
+			cancel()
+			return fmt.Errorf("failed to configure election: %w", err)
+		}
+	}
+
+	n.mu.Lock()
+	n.p2pNode = h
+	n.peerId = h.ID()
+	n.dht = kadDHT
+	n.pubsub = gossipSub
+	n.topics = node.NewTopicCache(nodeCtx, gossipSub, n.networkConfig.PeerScore)
+	n.hellos = make(map[peer.ID]*Hello)
+	n.ctx = nodeCtx
+	n.cancel = cancel
+	n.mu.Unlock()
+
+	h.Network().Notify(&network.NotifyBundle{DisconnectedF: n.peerDisconnected})
+	connectionManager := NewStreamConnectionManager(h, n.logger, n.dispatchRequest)
+	connectionManager.hello = n.hello
+	n.connectionManager = connectionManager
+	n.registerStreamHandlers()
+
+	report, err := config.BootstrapPeers(nodeCtx, h, n.networkConfig.BootstrapPeers, n.networkConfig.Bootstrap)
+	if err != nil {
+		n.logger.Warnf("Failed to connect to bootstrap peers: %v", err)
+	} else {
+		n.logBootstrapReport(report)
+		go config.MaintainBootstrap(nodeCtx, h, n.networkConfig.BootstrapPeers, n.networkConfig.Bootstrap, n.logBootstrapReport)
+	}
+
+	if n.networkConfig.EnableMDNS {
+		if err := n.startMDNS(nodeCtx); err != nil {
+			n.logger.Warnf("Failed to start mDNS discovery: %v", err)
+		}
+	}
+
 	return nil
 }
 
+// logBootstrapReport logs the outcome of a bootstrap run
+func (n *CoreNode) logBootstrapReport(report *config.BootstrapReport) {
+	for _, failed := range report.Failed {
+		n.logger.Warnf("Failed to connect to bootstrap peer %s after %d attempts: %v", failed.Address, failed.Attempts, failed.Err)
+	}
+	if err := report.Err(); err != nil {
+		n.logger.Warnf("Bootstrap not ready: %v", err)
+	} else if len(report.Succeeded) > 0 {
+		n.logger.Debugf("Connected to %d bootstrap peers", len(report.Succeeded))
+	}
+}
+
 // Start starts the node
 func (n *CoreNode) Start(ctx context.Context) error {
 	if n.State() != NodeStateStopped {
@@ -537,10 +847,32 @@ func (n *CoreNode) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize node: %w", err)
 	}
 
+	n.subscribeRegistryChanges()
+
+	if n.election != nil {
+		if n.Type() == NodeTypeLeader {
+			go n.election.run(n.context())
+		} else if n.Leader() == nil {
+			if err := n.retargetLeader(ctx); err != nil {
+				n.logger.Warnf("Failed to find the current leader: %v", err)
+			}
+		}
+	}
+
 	if err := n.Register(ctx); err != nil {
 		n.logger.Errorf("Failed to register node: %v", err)
 		// Don't fail startup on registration failure
 	}
+	n.startHealth()
+
+	if n.metrics != nil {
+		addr, err := n.metrics.Serve(n.config.MetricsAddress)
+		if err != nil {
+			n.logger.Warnf("Failed to serve metrics: %v", err)
+		} else {
+			n.logger.Infof("Serving metrics on http://%s/metrics", addr)
+		}
+	}
 
 	n.setState(NodeStateRunning)
 	n.logger.Info("Node started successfully")
@@ -554,11 +886,39 @@ func (n *CoreNode) Stop(ctx context.Context) error {
 
 	var errs []error
 
+	// Stop heartbeats before leaving the registry
+	n.stopHealth()
+
 	// Unregister from network
 	if err := n.Unregister(ctx); err != nil {
 		errs = append(errs, fmt.Errorf("failed to unregister: %w", err))
 	}
 
+	if err := n.metrics.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop metrics server: %w", err))
+	}
+
+	// Stop network services
+	n.unsubscribeRegistryChanges()
+	if n.topics != nil {
+		if err := n.topics.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to leave topics: %w", err))
+		}
+	}
+
+	if n.mdns != nil {
+		if err := n.mdns.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close mDNS: %w", err))
+		}
+		n.mdns = nil
+	}
+
+	if n.dht != nil {
+		if err := n.dht.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close DHT: %w", err))
+		}
+	}
+
 	// Stop libp2p host
 	if n.p2pNode != nil {
 		if err := n.p2pNode.Close(); err != nil {
@@ -566,6 +926,18 @@ func (n *CoreNode) Stop(ctx context.Context) error {
 		}
 	}
 
+	if n.cancel != nil {
+		n.cancel()
+	}
+
+	n.mu.Lock()
+	n.p2pNode = nil
+	n.dht = nil
+	n.pubsub = nil
+	n.topics = nil
+	n.connectionManager = nil
+	n.mu.Unlock()
+
 	if len(errs) > 0 {
 		n.setState(NodeStateError)
 		for _, err := range errs {
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+)
+
+// LeaderProtocol is the o-protocol ID served by leader nodes
+const LeaderProtocol = protocol.ID("/o/leader")
+
+// mdnsServiceName returns the mDNS service name for this node.
+// When a network name is configured and no explicit service name was set,
+// discovery is scoped to that network so only its members find each other.
+func (n *CoreNode) mdnsServiceName() string {
+	name := n.networkConfig.MDNSServiceName
+	if n.config.NetworkName != "" && (name == "" || name == config.DefaultMDNSServiceName) {
+		return config.MDNSServiceNameForNetwork(n.config.NetworkName)
+	}
+	return name
+}
+
+// startMDNS starts local discovery and watches discovered peers for a leader
+func (n *CoreNode) startMDNS(ctx context.Context) error {
+	service, err := config.StartMDNS(ctx, n.p2pNode, n.mdnsServiceName(), n.handlePeerDiscovered)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	n.mdns = service
+	n.mu.Unlock()
+
+	n.logger.Debugf("mDNS discovery started (service %s)", n.mdnsServiceName())
+	return nil
+}
+
+// handlePeerDiscovered adopts a discovered peer as leader if none is configured
+// and the peer advertises the leader protocol
+func (n *CoreNode) handlePeerDiscovered(info peer.AddrInfo) {
+	if n.Type() == NodeTypeLeader || n.Leader() != nil {
+		return
+	}
+
+	h := n.Host()
+	if h == nil {
+		return
+	}
+
+	supported, err := h.Peerstore().SupportsProtocols(info.ID, LeaderProtocol)
+	if err != nil || len(supported) == 0 {
+		return
+	}
+
+	leader := NewOAddress("o://leader")
+	leader.SetTransports(p2pAddrs(info))
+	n.setLeader(leader)
+	n.logger.Infof("Discovered leader %s via mDNS", info.ID)
+
+	if n.State() == NodeStateRunning {
+		go func() {
+			if err := n.Register(n.context()); err != nil {
+				n.logger.Warnf("Failed to register with discovered leader: %v", err)
+				return
+			}
+			// Start found no leader, so it did not start the heartbeat loop
+			n.startHealth()
+		}()
+	}
+}
+
+// p2pAddrs returns the peer's addresses with the /p2p component appended
+func p2pAddrs(info peer.AddrInfo) []multiaddr.Multiaddr {
+	addrs, err := peer.AddrInfoToP2pAddrs(&info)
+	if err != nil {
+		return []multiaddr.Multiaddr{}
+	}
+	return addrs
+}
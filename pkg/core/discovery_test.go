@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+)
+
+// newMDNSNode creates a loopback node discovering peers of networkName over mDNS
+func newMDNSNode(address string, nodeType NodeType, networkName string, enabled bool) *CoreNode {
+	node := newLoopbackNode(address, nodeType, nil)
+	node.config.NetworkName = networkName
+	node.networkConfig.EnableMDNS = enabled
+	return node
+}
+
+func TestMDNSServiceName(t *testing.T) {
+	node := newMDNSNode("o://agents/planner", NodeTypeAgent, "Dev Cluster", true)
+	if name := node.mdnsServiceName(); name != "_olane-dev-cluster._udp" {
+		t.Errorf("Expected network scoped service name, got %s", name)
+	}
+
+	node.networkConfig.MDNSServiceName = "_custom._udp"
+	if name := node.mdnsServiceName(); name != "_custom._udp" {
+		t.Errorf("Expected explicit service name to win, got %s", name)
+	}
+
+	node = newMDNSNode("o://agents/planner", NodeTypeAgent, "", true)
+	if name := node.mdnsServiceName(); name != config.DefaultMDNSServiceName {
+		t.Errorf("Expected default service name, got %s", name)
+	}
+}
+
+func TestMDNSDiscoversLeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	networkName := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	leader := newMDNSNode("o://leader", NodeTypeLeader, networkName, true)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	agent := newMDNSNode("o://agents/planner", NodeTypeAgent, networkName, true)
+	if err := agent.Start(ctx); err != nil {
+		t.Fatalf("Failed to start agent: %v", err)
+	}
+	defer agent.Stop(ctx)
+
+	waitUntil(t, ctx, "agent to connect to the leader", func() bool {
+		return agent.Host().Network().Connectedness(leader.ID()) == network.Connected
+	})
+	waitUntil(t, ctx, "agent to adopt the leader", func() bool {
+		return agent.Leader() != nil
+	})
+	if id, err := agent.Leader().PeerID(); err != nil || id != leader.ID() {
+		t.Errorf("Expected leader %s, got %s (%v)", leader.ID(), id, err)
+	}
+	waitUntil(t, ctx, "agent to register", func() bool {
+		return leader.Registry().Len() == 1
+	})
+	waitUntil(t, ctx, "agent to start its heartbeats", func() bool {
+		agent.mu.RLock()
+		defer agent.mu.RUnlock()
+		return agent.health != nil
+	})
+}
+
+func TestMDNSDisabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	networkName := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	leader := newMDNSNode("o://leader", NodeTypeLeader, networkName, true)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	agent := newMDNSNode("o://agents/planner", NodeTypeAgent, networkName, false)
+	if err := agent.Start(ctx); err != nil {
+		t.Fatalf("Failed to start agent: %v", err)
+	}
+	defer agent.Stop(ctx)
+
+	if agent.mdns != nil {
+		t.Error("Expected no mDNS service when discovery is disabled")
+	}
+	// The leader keeps advertising; give a discovering peer time to find it
+	time.Sleep(2 * time.Second)
+	if agent.Host().Network().Connectedness(leader.ID()) == network.Connected {
+		t.Error("Expected agent not to connect without mDNS")
+	}
+	if agent.Leader() != nil {
+		t.Errorf("Expected no leader without mDNS, got %s", agent.Leader())
+	}
+}
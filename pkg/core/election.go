@@ -0,0 +1,565 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// ElectionProtocol carries leader election, lease renewal and registry replication
+// between leader candidates, and leader lookups from other nodes
+const ElectionProtocol = protocol.ID("/olane/election/1.0.0")
+
+// Election message types
+const (
+	electionVote      = "vote"
+	electionHeartbeat = "heartbeat"
+	electionLeader    = "leader"
+)
+
+// ElectionRole is a leader candidate's role in the current term
+type ElectionRole string
+
+const (
+	ElectionRoleFollower  ElectionRole = "follower"
+	ElectionRoleCandidate ElectionRole = "candidate"
+	ElectionRoleLeader    ElectionRole = "leader"
+)
+
+// ElectionConfig configures leader high availability. On leader nodes it enables
+// election among the candidates: the elected leader holds a lease it renews with
+// heartbeats that also replicate its registry, and a majority of candidates elects
+// a new leader when the lease lapses. On other nodes the candidates are asked for
+// the current leader whenever a call routed through the leader fails.
+type ElectionConfig struct {
+	// Candidates lists a transport for every leader candidate, each ending in /p2p/<peer ID>.
+	// A candidate's own transport may be included.
+	Candidates []string
+	// HeartbeatInterval sets how often the elected leader renews its lease
+	HeartbeatInterval time.Duration
+	// ElectionTimeout is how long a follower waits for a heartbeat before standing for
+	// election, randomized between one and two timeouts. A leader that cannot reach a
+	// majority for this long steps down.
+	ElectionTimeout time.Duration
+}
+
+// DefaultElectionConfig returns the default election timings without candidates
+func DefaultElectionConfig() *ElectionConfig {
+	return &ElectionConfig{
+		HeartbeatInterval: 500 * time.Millisecond,
+		ElectionTimeout:   2 * time.Second,
+	}
+}
+
+// ElectionStatus describes a node's view of the current election
+type ElectionStatus struct {
+	Term   uint64       `json:"term"`
+	Role   ElectionRole `json:"role"`
+	Leader peer.ID      `json:"leader,omitempty"`
+}
+
+// electionMessage is a request on ElectionProtocol
+type electionMessage struct {
+	Type            string            `json:"type"`
+	Term            uint64            `json:"term"`
+	Transports      []string          `json:"transports,omitempty"`
+	RegistryVersion uint64            `json:"registryVersion"`
+	Registry        *RegistrySnapshot `json:"registry,omitempty"`
+}
+
+// electionReply is a response on ElectionProtocol
+type electionReply struct {
+	Term             uint64   `json:"term"`
+	Granted          bool     `json:"granted"`
+	RegistryVersion  uint64   `json:"registryVersion"`
+	Leader           string   `json:"leader,omitempty"`
+	LeaderTransports []string `json:"leaderTransports,omitempty"`
+}
+
+// election runs the lease-based election among leader candidates. Its lock is
+// never taken while holding the node's lock.
+type election struct {
+	node   *CoreNode
+	config *ElectionConfig
+	self   peer.ID
+	peers  map[peer.ID]struct{} // candidates other than this node
+
+	mu            sync.Mutex
+	term          uint64
+	votedFor      peer.ID
+	role          ElectionRole
+	leader        peer.ID
+	leaderAddrs   []string
+	deadline      time.Time
+	lastHeartbeat time.Time
+	lastMajority  time.Time
+	acked         map[peer.ID]uint64
+}
+
+// newElection parses the candidates and adds their addresses to the peerstore
+func newElection(n *CoreNode, cfg *ElectionConfig, ps peerstore.Peerstore, self peer.ID) (*election, error) {
+	defaults := DefaultElectionConfig()
+	merged := *cfg
+	if merged.HeartbeatInterval <= 0 {
+		merged.HeartbeatInterval = defaults.HeartbeatInterval
+	}
+	if merged.ElectionTimeout <= 0 {
+		merged.ElectionTimeout = defaults.ElectionTimeout
+	}
+	if merged.ElectionTimeout <= merged.HeartbeatInterval {
+		return nil, fmt.Errorf("election timeout %s must exceed heartbeat interval %s", merged.ElectionTimeout, merged.HeartbeatInterval)
+	}
+
+	e := &election{
+		node:   n,
+		config: &merged,
+		self:   self,
+		peers:  make(map[peer.ID]struct{}),
+		role:   ElectionRoleFollower,
+		acked:  make(map[peer.ID]uint64),
+	}
+	for _, candidate := range cfg.Candidates {
+		ma, err := multiaddr.NewMultiaddr(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid candidate %s: %w", candidate, err)
+		}
+		info, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return nil, fmt.Errorf("candidate %s must end in /p2p/<peer ID>: %w", candidate, err)
+		}
+		if info.ID == self {
+			continue
+		}
+		ps.AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+		e.peers[info.ID] = struct{}{}
+	}
+	return e, nil
+}
+
+// resetDeadline schedules the next election; callers hold e.mu
+func (e *election) resetDeadline() {
+	timeout := e.config.ElectionTimeout
+	e.deadline = time.Now().Add(timeout + time.Duration(rand.Int63n(int64(timeout))))
+}
+
+// status returns the current term, role and leader
+func (e *election) status() *ElectionStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &ElectionStatus{Term: e.term, Role: e.role, Leader: e.leader}
+}
+
+// observeTerm steps down to follower if term is newer than ours; callers hold e.mu
+func (e *election) observeTerm(term uint64) {
+	if term <= e.term {
+		return
+	}
+	e.term = term
+	e.votedFor = ""
+	if e.role != ElectionRoleFollower {
+		e.node.logger.Infof("Stepping down in term %d", term)
+		e.role = ElectionRoleFollower
+		e.leader = ""
+		e.resetDeadline()
+	}
+}
+
+// run drives the election until ctx is done
+func (e *election) run(ctx context.Context) {
+	e.mu.Lock()
+	e.resetDeadline()
+	e.mu.Unlock()
+
+	ticker := time.NewTicker(e.config.HeartbeatInterval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.tick(ctx)
+		}
+	}
+}
+
+// tick renews the lease on the leader and starts an election on followers whose deadline passed
+func (e *election) tick(ctx context.Context) {
+	now := time.Now()
+
+	e.mu.Lock()
+	if e.role == ElectionRoleLeader {
+		if now.Sub(e.lastMajority) > e.config.ElectionTimeout {
+			e.node.logger.Warnf("Lost contact with a majority of candidates, stepping down in term %d", e.term)
+			e.role = ElectionRoleFollower
+			e.leader = ""
+			e.resetDeadline()
+			e.mu.Unlock()
+			return
+		}
+		due := now.Sub(e.lastHeartbeat) >= e.config.HeartbeatInterval
+		if due {
+			e.lastHeartbeat = now
+		}
+		term := e.term
+		e.mu.Unlock()
+		if due {
+			e.heartbeat(ctx, term)
+		}
+		return
+	}
+	expired := now.After(e.deadline)
+	e.mu.Unlock()
+
+	if expired {
+		e.campaign(ctx)
+	}
+}
+
+// campaign stands for election in a new term and becomes leader on a majority of votes
+func (e *election) campaign(ctx context.Context) {
+	e.mu.Lock()
+	e.term++
+	term := e.term
+	e.role = ElectionRoleCandidate
+	e.votedFor = e.self
+	e.leader = ""
+	e.resetDeadline()
+	e.mu.Unlock()
+
+	e.node.logger.Debugf("Standing for election in term %d", term)
+	msg := &electionMessage{Type: electionVote, Term: term, RegistryVersion: e.node.registry.Version()}
+
+	var votes sync.WaitGroup
+	granted := 1
+	var grantedMu sync.Mutex
+	for id := range e.peers {
+		votes.Add(1)
+		go func(id peer.ID) {
+			defer votes.Done()
+			reply, err := e.send(ctx, id, msg)
+			if err != nil {
+				e.node.logger.Debugf("No vote from %s: %v", id, err)
+				return
+			}
+			e.mu.Lock()
+			e.observeTerm(reply.Term)
+			e.mu.Unlock()
+			if reply.Granted {
+				grantedMu.Lock()
+				granted++
+				grantedMu.Unlock()
+			}
+		}(id)
+	}
+	votes.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.role != ElectionRoleCandidate || e.term != term || granted*2 <= len(e.peers)+1 {
+		return
+	}
+
+	e.role = ElectionRoleLeader
+	e.leader = e.self
+	e.leaderAddrs = e.transports()
+	e.lastHeartbeat = time.Time{}
+	e.lastMajority = time.Now()
+	e.acked = make(map[peer.ID]uint64)
+	e.node.registry.touchAll()
+	e.node.setLeader(nil)
+	e.node.logger.Infof("Elected leader for term %d with %d of %d votes", term, granted, len(e.peers)+1)
+}
+
+// heartbeat renews the lease with every follower, sending the registry to those behind it
+func (e *election) heartbeat(ctx context.Context, term uint64) {
+	snapshot := e.node.registry.Snapshot()
+	transports := e.transports()
+
+	var wg sync.WaitGroup
+	acks := 1
+	var acksMu sync.Mutex
+	for id := range e.peers {
+		e.mu.Lock()
+		behind := e.acked[id] != snapshot.Version
+		e.mu.Unlock()
+
+		msg := &electionMessage{Type: electionHeartbeat, Term: term, Transports: transports, RegistryVersion: snapshot.Version}
+		if behind {
+			msg.Registry = snapshot
+		}
+
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+			reply, err := e.send(ctx, id, msg)
+			if err != nil {
+				e.node.logger.Debugf("Heartbeat to %s failed: %v", id, err)
+				return
+			}
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.observeTerm(reply.Term)
+			if reply.Granted {
+				e.acked[id] = reply.RegistryVersion
+				acksMu.Lock()
+				acks++
+				acksMu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.role == ElectionRoleLeader && e.term == term && acks*2 > len(e.peers)+1 {
+		e.lastMajority = time.Now()
+	}
+}
+
+// handleVote answers a vote request. A vote is granted once per term, and only to
+// candidates whose registry is at least as recent as ours so no acknowledged
+// registration is lost.
+func (e *election) handleVote(from peer.ID, msg *electionMessage) *electionReply {
+	version := e.node.registry.Version()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observeTerm(msg.Term)
+
+	reply := &electionReply{Term: e.term, RegistryVersion: version}
+	if msg.Term < e.term {
+		return reply
+	}
+	if (e.votedFor == "" || e.votedFor == from) && msg.RegistryVersion >= version {
+		e.votedFor = from
+		e.resetDeadline()
+		reply.Granted = true
+	}
+	return reply
+}
+
+// handleHeartbeat accepts a lease renewal from the leader of the current or a newer term
+func (e *election) handleHeartbeat(from peer.ID, msg *electionMessage) *electionReply {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.observeTerm(msg.Term)
+
+	if msg.Term < e.term {
+		return &electionReply{Term: e.term, RegistryVersion: e.node.registry.Version()}
+	}
+
+	e.role = ElectionRoleFollower
+	e.resetDeadline()
+	if e.leader != from {
+		e.node.logger.Infof("Following leader %s in term %d", from, msg.Term)
+	}
+	e.leader = from
+	e.leaderAddrs = msg.Transports
+	e.node.setLeader(leaderAddress(from, msg.Transports))
+
+	if msg.Registry != nil {
+		e.node.registry.Restore(msg.Registry)
+	}
+	return &electionReply{Term: e.term, Granted: true, RegistryVersion: e.node.registry.Version()}
+}
+
+// handleLeaderQuery reports the leader this node knows of
+func (e *election) handleLeaderQuery() *electionReply {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	reply := &electionReply{Term: e.term}
+	if e.leader != "" {
+		reply.Leader = e.leader.String()
+		reply.LeaderTransports = e.leaderAddrs
+	}
+	return reply
+}
+
+// handleStream serves a single request on ElectionProtocol. Only candidates may
+// vote or send heartbeats; any node may ask for the leader.
+func (e *election) handleStream(s network.Stream) {
+	defer s.Close()
+	_ = s.SetDeadline(time.Now().Add(e.config.ElectionTimeout))
+
+	var msg electionMessage
+	if err := json.NewDecoder(s).Decode(&msg); err != nil {
+		s.Reset()
+		return
+	}
+
+	from := s.Conn().RemotePeer()
+	var reply *electionReply
+	switch msg.Type {
+	case electionLeader:
+		reply = e.handleLeaderQuery()
+	case electionVote, electionHeartbeat:
+		if _, ok := e.peers[from]; !ok {
+			e.node.logger.Warnf("Rejected %s from %s: not a leader candidate", msg.Type, from)
+			s.Reset()
+			return
+		}
+		if msg.Type == electionVote {
+			reply = e.handleVote(from, &msg)
+		} else {
+			reply = e.handleHeartbeat(from, &msg)
+		}
+	default:
+		s.Reset()
+		return
+	}
+
+	if err := json.NewEncoder(s).Encode(reply); err != nil {
+		s.Reset()
+	}
+}
+
+// transports returns this node's listen addresses, or nil once the node has stopped
+func (e *election) transports() []string {
+	h := e.node.Host()
+	if h == nil {
+		return nil
+	}
+	var result []string
+	for _, addr := range h.Addrs() {
+		result = append(result, addr.String())
+	}
+	return result
+}
+
+// send delivers a message to a candidate and waits for its reply
+func (e *election) send(ctx context.Context, id peer.ID, msg *electionMessage) (*electionReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.config.HeartbeatInterval)
+	defer cancel()
+
+	h := e.node.Host()
+	if h == nil {
+		return nil, fmt.Errorf("node is not initialized")
+	}
+	s, err := h.NewStream(ctx, id, ElectionProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(s).Encode(msg); err != nil {
+		s.Reset()
+		return nil, err
+	}
+	if err := s.CloseWrite(); err != nil {
+		s.Reset()
+		return nil, err
+	}
+
+	var reply electionReply
+	if err := json.NewDecoder(s).Decode(&reply); err != nil {
+		s.Reset()
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// discoverLeader asks the candidates for the current leader
+func (e *election) discoverLeader(ctx context.Context) (*OAddress, error) {
+	for id := range e.peers {
+		reply, err := e.send(ctx, id, &electionMessage{Type: electionLeader})
+		if err != nil || reply.Leader == "" {
+			continue
+		}
+		leader, err := peer.Decode(reply.Leader)
+		if err != nil {
+			continue
+		}
+		return leaderAddress(leader, reply.LeaderTransports), nil
+	}
+	return nil, fmt.Errorf("no leader candidate knows the current leader")
+}
+
+// leaderAddress builds the leader's o-address from its peer ID and transports
+func leaderAddress(id peer.ID, transports []string) *OAddress {
+	return peerAddress("o://leader", id, transports)
+}
+
+// peerAddress builds an o-address whose transports dial the given peer
+func peerAddress(address string, id peer.ID, transports []string) *OAddress {
+	suffix, _ := multiaddr.NewMultiaddr("/p2p/" + id.String())
+	addrs := make([]interface{}, 0, len(transports))
+	for _, t := range transports {
+		ma, err := multiaddr.NewMultiaddr(t)
+		if err != nil {
+			continue
+		}
+		if _, err := ma.ValueForProtocol(multiaddr.P_P2P); err != nil {
+			ma = ma.Encapsulate(suffix)
+		}
+		addrs = append(addrs, ma)
+	}
+	return NewOAddress(address, addrs...)
+}
+
+// ElectionStatus returns the node's view of the leader election, or nil if
+// election is not configured
+func (n *CoreNode) ElectionStatus() *ElectionStatus {
+	if n.election == nil {
+		return nil
+	}
+	return n.election.status()
+}
+
+// IsLeader reports whether this node is a leader and, when election is
+// configured, currently holds the lease
+func (n *CoreNode) IsLeader() bool {
+	if n.Type() != NodeTypeLeader {
+		return false
+	}
+	return n.election == nil || n.election.status().Role == ElectionRoleLeader
+}
+
+// requireLeadership returns ErrNotLeader, naming the current leader if known,
+// when this node is a leader candidate without the lease
+func (n *CoreNode) requireLeadership() error {
+	if n.IsLeader() {
+		return nil
+	}
+	reply := n.election.handleLeaderQuery()
+	return ErrNotLeader(reply.Leader, reply.LeaderTransports)
+}
+
+// shouldRetarget reports whether a failed call routed through the leader should
+// be retried after looking up the current leader. Only calls the old leader cannot
+// have run are retried: those that failed to reach it and those it refused as
+// no longer the leader. Calls to explicit transports are not routed through the
+// leader and are never retried.
+func (n *CoreNode) shouldRetarget(explicit bool, response *OResponse, err error) bool {
+	if n.election == nil || n.IsLeader() || explicit {
+		return false
+	}
+	if err != nil {
+		return isUndelivered(err)
+	}
+	return response != nil && response.Error != nil && response.Error.Code == ErrorCodeNotLeader
+}
+
+// retargetLeader looks up the current leader among the candidates and routes
+// future calls through it
+func (n *CoreNode) retargetLeader(ctx context.Context) error {
+	leader, err := n.election.discoverLeader(ctx)
+	if err != nil {
+		return err
+	}
+	n.setLeader(leader)
+	n.logger.Infof("Retargeted to leader %s", leader.AllTransports())
+	return nil
+}
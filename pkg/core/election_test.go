@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/olane-labs/olane-go/pkg/utils"
+)
+
+// freePort reserves a loopback TCP port so candidates know each other's transports before starting
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// newLeaderCluster starts size leader candidates on loopback and returns them with their transports
+func newLeaderCluster(t *testing.T, ctx context.Context, size int) ([]*CoreNode, []string) {
+	var candidates []string
+	var listeners []string
+	for i := 0; i < size; i++ {
+		key, err := utils.DeriveEd25519Key(fmt.Sprintf("%s-leader-%d", t.Name(), i))
+		if err != nil {
+			t.Fatalf("Failed to derive key: %v", err)
+		}
+		id, _ := peer.IDFromPrivateKey(key)
+		listener := fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", freePort(t))
+		listeners = append(listeners, listener)
+		candidates = append(candidates, listener+"/p2p/"+id.String())
+	}
+
+	var leaders []*CoreNode
+	for i := 0; i < size; i++ {
+		n := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+		n.config.Seed = fmt.Sprintf("%s-leader-%d", t.Name(), i)
+		n.networkConfig.Listeners = []string{listeners[i]}
+		n.config.Election = &ElectionConfig{
+			Candidates:        candidates,
+			HeartbeatInterval: 100 * time.Millisecond,
+			ElectionTimeout:   500 * time.Millisecond,
+		}
+		if err := n.Start(ctx); err != nil {
+			t.Fatalf("Failed to start leader %d: %v", i, err)
+		}
+		leaders = append(leaders, n)
+	}
+	return leaders, candidates
+}
+
+// waitForLeader waits until exactly one running candidate holds the lease and the others follow it
+func waitForLeader(t *testing.T, ctx context.Context, nodes []*CoreNode) *CoreNode {
+	for {
+		var elected *CoreNode
+		agreed := true
+		for _, n := range nodes {
+			if n.IsLeader() {
+				if elected != nil {
+					agreed = false
+				}
+				elected = n
+			}
+		}
+		if elected != nil && agreed {
+			for _, n := range nodes {
+				if n.ElectionStatus().Leader != elected.ID() {
+					agreed = false
+				}
+			}
+			if agreed {
+				return elected
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for a leader")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// waitForRegistrySize waits until every node's registry replica holds size entries
+func waitForRegistrySize(t *testing.T, ctx context.Context, nodes []*CoreNode, size int) {
+	for {
+		done := true
+		for _, n := range nodes {
+			if n.Registry().Len() != size {
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for %d registry entries", size)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	calc := &RegistryEntry{
+		PeerID:        "12D3KooWQYhTNQdmr3ArTeUHRYzFg94BKyTkoWBDWez9kSCVe2Xo",
+		Address:       "o://leader/tools/calc",
+		StaticAddress: "o://calc",
+		Protocols:     []string{"/o/leader/tools/calc"},
+	}
+	if err := registry.Commit(calc); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if err := registry.Commit(&RegistryEntry{PeerID: "not-a-peer", Address: "o://x"}); err == nil {
+		t.Error("Expected error for an invalid peer ID")
+	}
+
+	if results := registry.Search(&RegistryQuery{StaticAddress: "o://calc"}); len(results) != 1 || results[0].Address != calc.Address {
+		t.Errorf("Expected calc for static address search, got %v", results)
+	}
+	if results := registry.Search(&RegistryQuery{Protocol: "/o/other"}); len(results) != 0 {
+		t.Errorf("Expected no results for unknown protocol, got %v", results)
+	}
+	if results := registry.FindAll(); len(results) != 1 || results[0].RegisteredAt == 0 {
+		t.Errorf("Expected one timestamped entry, got %v", results)
+	}
+
+	id, _ := peer.Decode(calc.PeerID)
+	if allowed, _ := registry.AuthorizePeer(id); !allowed {
+		t.Error("Expected registered peer to be authorized")
+	}
+
+	// Liveness alone does not change the version, so it is not replicated
+	version := registry.Version()
+	if !registry.Heartbeat(calc.PeerID) || registry.Version() != version {
+		t.Errorf("Expected heartbeat to keep version %d, got %d", version, registry.Version())
+	}
+	registry.SetHealth(calc.PeerID, RegistryHealthUnhealthy)
+	if registry.Version() != version+1 {
+		t.Errorf("Expected health change to bump the version to %d, got %d", version+1, registry.Version())
+	}
+	if registry.Heartbeat(calc.PeerID); registry.Version() != version+2 {
+		t.Errorf("Expected recovery to bump the version to %d, got %d", version+2, registry.Version())
+	}
+
+	snapshot := registry.Snapshot()
+	replica := NewRegistry()
+	replica.Restore(snapshot)
+	if replica.Version() != registry.Version() || replica.Len() != 1 {
+		t.Errorf("Expected replica at version %d with 1 entry, got version %d with %d", registry.Version(), replica.Version(), replica.Len())
+	}
+
+	if !registry.Remove(calc.PeerID) || registry.Len() != 0 {
+		t.Error("Expected entry to be removed")
+	}
+	if replica.Len() != 1 {
+		t.Error("Expected replica to be independent of the original")
+	}
+}
+
+func TestLeaderElectionAndFailover(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	leaders, candidates := newLeaderCluster(t, ctx, 3)
+	for _, n := range leaders {
+		defer n.Stop(ctx)
+	}
+	elected := waitForLeader(t, ctx, leaders)
+
+	// The tool has no configured leader and finds it through the candidates
+	tool := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	tool.config.Election = &ElectionConfig{Candidates: candidates}
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	waitForRegistrySize(t, ctx, leaders, 1)
+
+	// Followers refuse registry writes and name the leader
+	var follower *CoreNode
+	for _, n := range leaders {
+		if n != elected {
+			follower = n
+			break
+		}
+	}
+	registry := NewOAddress(RegistryAddress)
+	registry.SetTransports(dialAddress(t, follower).LibP2PTransports())
+	response, err := tool.Use(ctx, registry, "commit", map[string]interface{}{"peerId": tool.ID().String(), "address": "o://x"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to call follower: %v", err)
+	}
+	if response.Error == nil || response.Error.Code != ErrorCodeNotLeader {
+		t.Errorf("Expected not-leader error from follower, got %+v", response)
+	}
+
+	// Stop the leader; the survivors elect a new one from the replicated registry
+	if err := elected.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop leader: %v", err)
+	}
+	var survivors []*CoreNode
+	for _, n := range leaders {
+		if n != elected {
+			survivors = append(survivors, n)
+		}
+	}
+	successor := waitForLeader(t, ctx, survivors)
+	if successor.Registry().Len() != 1 {
+		t.Errorf("Expected successor to hold the replicated registry, got %d entries", successor.Registry().Len())
+	}
+
+	// The tool still routes through the stopped leader and retargets transparently
+	address := NewOAddress(RegistryAddress)
+	response, err = tool.Use(ctx, address, "find_all", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to use registry after failover: %v", err)
+	}
+	if response.Error != nil {
+		t.Fatalf("Expected registry results after failover, got %v", response.Error)
+	}
+	if len(address.LibP2PTransports()) != 0 {
+		t.Errorf("Expected the caller's address to be left without transports, got %v", address.LibP2PTransports())
+	}
+	if tool.Leader() == nil || len(tool.Leader().LibP2PTransports()) == 0 {
+		t.Fatal("Expected tool to know the new leader")
+	}
+	if id, _ := tool.Leader().LibP2PTransports()[0].ValueForProtocol(multiaddr.P_P2P); id != successor.ID().String() {
+		t.Errorf("Expected tool to retarget to %s, got %s", successor.ID(), id)
+	}
+}
+
+func TestShouldRetarget(t *testing.T) {
+	follower := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	follower.election = &election{}
+
+	notLeader := NewOErrorResponse("1", ErrorCodeNotLeader, "not the leader", nil)
+	dialFailed := &undeliveredError{err: errors.New("dial failed")}
+	tests := []struct {
+		name     string
+		explicit bool
+		response *OResponse
+		err      error
+		want     bool
+	}{
+		{"dial failure", false, nil, fmt.Errorf("failed to connect: %w", dialFailed), true},
+		{"not leader", false, notLeader, nil, true},
+		{"read failure", false, nil, errors.New("failed to read response: stream reset"), false},
+		{"other error response", false, NewOErrorResponse("1", ErrorCodeGeneral, "boom", nil), nil, false},
+		{"explicit transports", true, nil, dialFailed, false},
+	}
+	for _, tt := range tests {
+		if got := follower.shouldRetarget(tt.explicit, tt.response, tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+	"github.com/olane-labs/olane-go/pkg/node"
+)
+
+// eventsSegment separates a node's address from its event names in topic names
+const eventsSegment = "/events/"
+
+// Event is an event published by a node on its address's event topics
+type Event struct {
+	// Address is the o-address of the node that emitted the event
+	Address string `json:"address"`
+	// Name is the event name, e.g. "updated"
+	Name string `json:"event"`
+	// Payload is the JSON encoded event payload
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Timestamp is when the event was emitted, in Unix milliseconds
+	Timestamp int64 `json:"timestamp"`
+	// PeerID is the peer that published the event. GossipSub signs every message
+	// and verifies the signature on receipt, so it cannot be spoofed.
+	PeerID peer.ID `json:"peerId"`
+}
+
+// Time returns the emission time of the event
+func (e *Event) Time() time.Time {
+	return time.UnixMilli(e.Timestamp)
+}
+
+// Decode unmarshals the event payload into v
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// EventHandler is called for every event received on a subscription
+type EventHandler func(ctx context.Context, event *Event)
+
+// EventSubscription is an active subscription created by On
+type EventSubscription struct {
+	sub *node.TopicSubscription
+}
+
+// Topic returns the GossipSub topic of the subscription
+func (s *EventSubscription) Topic() string {
+	return s.sub.Topic()
+}
+
+// Cancel stops the subscription and waits for its handler to return
+func (s *EventSubscription) Cancel() {
+	s.sub.Cancel()
+}
+
+// EventTopic returns the GossipSub topic for an address's event,
+// e.g. o://services/weather and "updated" give /o/services/weather/events/updated
+func EventTopic(address *OAddress, event string) string {
+	return address.Protocol() + eventsSegment + event
+}
+
+// validateEventName checks that an event name can be used in a topic
+func validateEventName(event string) error {
+	if event == "" {
+		return fmt.Errorf("event name must not be empty")
+	}
+	if strings.ContainsAny(event, " \t\r\n") {
+		return fmt.Errorf("invalid event name %q: must not contain whitespace", event)
+	}
+	return nil
+}
+
+// topicCache returns the cache of GossipSub topics joined by this node
+func (n *CoreNode) topicCache() (*node.TopicCache, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.topics == nil {
+		return nil, fmt.Errorf("pubsub is not enabled on this node")
+	}
+	return n.topics, nil
+}
+
+// Emit publishes an event on this node's address, e.g. Emit(ctx, "updated", data)
+// on o://services/weather publishes to /o/services/weather/events/updated.
+// The payload is encoded as JSON.
+func (n *CoreNode) Emit(ctx context.Context, event string, payload interface{}) error {
+	if err := validateEventName(event); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode event payload: %w", err)
+	}
+
+	envelope, err := json.Marshal(&Event{
+		Address:   n.address.String(),
+		Name:      event,
+		Payload:   data,
+		Timestamp: time.Now().UnixMilli(),
+		PeerID:    n.ID(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	name := EventTopic(n.address, event)
+	topics, err := n.topicCache()
+	if err != nil {
+		return err
+	}
+	if err := topics.Publish(ctx, name, envelope); err != nil {
+		return fmt.Errorf("failed to publish event to %s: %w", name, err)
+	}
+
+	n.logger.Debugf("Emitted event %s", name)
+	return nil
+}
+
+// On subscribes to an event emitted by the node at address. The handler runs on
+// the subscription's own goroutine until the subscription is cancelled or the
+// node stops. Events emitted by this node on the same topic are delivered too.
+func (n *CoreNode) On(address *OAddress, event string, handler EventHandler) (*EventSubscription, error) {
+	if err := validateEventName(event); err != nil {
+		return nil, err
+	}
+
+	name := EventTopic(address, event)
+	topics, err := n.topicCache()
+	if err != nil {
+		return nil, err
+	}
+	sub, err := topics.SubscribeFunc(name, func(ctx context.Context, msg *pubsub.Message) {
+		event, err := decodeEvent(msg)
+		if err != nil {
+			n.logger.Warnf("Dropped event on %s from %s: %v", name, msg.GetFrom(), err)
+			return
+		}
+		handler(ctx, event)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EventSubscription{sub: sub}, nil
+}
+
+// decodeEvent decodes an event envelope and checks it against the message's
+// signature-verified author
+func decodeEvent(msg *pubsub.Message) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return nil, fmt.Errorf("invalid event envelope: %w", err)
+	}
+
+	from := msg.GetFrom()
+	if event.PeerID != from {
+		return nil, fmt.Errorf("envelope peer %s does not match signer %s", event.PeerID, from)
+	}
+	return &event, nil
+}
+
+// EventValidator returns a validator for the GossipSub topics under /o/ that
+// rejects messages larger than maxSize bytes (0 means no limit) and, on event
+// topics, envelopes that are malformed, published on another address's topic or
+// signed by a peer other than the one they name. Senders, which may be nil,
+// restricts who may publish, e.g. to peers registered with the leader.
+// Add it to Libp2pConfig.MessageValidator.
+func EventValidator(maxSize int, senders config.PeerAuthorizer) *config.TopicValidator {
+	return &config.TopicValidator{
+		Prefix:  "/o/",
+		MaxSize: maxSize,
+		Senders: senders,
+		Schema: func(msg *pubsub.Message) error {
+			topic := msg.GetTopic()
+			if !strings.Contains(topic, eventsSegment) {
+				return nil
+			}
+
+			event, err := decodeEvent(msg)
+			if err != nil {
+				return err
+			}
+			if expected := EventTopic(NewOAddress(event.Address), event.Name); expected != topic {
+				return fmt.Errorf("event %s from %s does not belong on topic %s", event.Name, event.Address, topic)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestEventTopic(t *testing.T) {
+	topic := EventTopic(NewOAddress("o://services/weather"), "updated")
+	if topic != "/o/services/weather/events/updated" {
+		t.Errorf("Expected /o/services/weather/events/updated, got %s", topic)
+	}
+}
+
+func TestEmitOn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	weather := newLoopbackNode("o://services/weather", NodeTypeNode, nil)
+	if err := weather.Start(ctx); err != nil {
+		t.Fatalf("Failed to start emitter: %v", err)
+	}
+	defer weather.Stop(ctx)
+
+	listener := newLoopbackNode("o://services/dashboard", NodeTypeNode, nil)
+	if err := listener.Start(ctx); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	defer listener.Stop(ctx)
+
+	if err := listener.Host().Connect(ctx, peer.AddrInfo{ID: weather.ID(), Addrs: weather.Host().Addrs()}); err != nil {
+		t.Fatalf("Failed to connect nodes: %v", err)
+	}
+
+	events := make(chan *Event, 10)
+	sub, err := listener.On(weather.Address(), "updated", func(ctx context.Context, event *Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer sub.Cancel()
+
+	// The GossipSub mesh forms asynchronously, so emit until the listener hears it
+	var received *Event
+	for received == nil {
+		if err := weather.Emit(ctx, "updated", map[string]interface{}{"temp": 21}); err != nil {
+			t.Fatalf("Failed to emit: %v", err)
+		}
+		select {
+		case received = <-events:
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for event")
+		}
+	}
+
+	if received.Address != "o://services/weather" || received.Name != "updated" {
+		t.Errorf("Expected updated event from o://services/weather, got %s from %s", received.Name, received.Address)
+	}
+	if received.PeerID != weather.ID() {
+		t.Errorf("Expected peer ID %s, got %s", weather.ID(), received.PeerID)
+	}
+	if received.Time().IsZero() {
+		t.Error("Expected event timestamp")
+	}
+
+	var payload struct {
+		Temp int `json:"temp"`
+	}
+	if err := received.Decode(&payload); err != nil || payload.Temp != 21 {
+		t.Errorf("Expected payload temp 21, got %d (%v)", payload.Temp, err)
+	}
+}
+
+func TestStopLeavesSubscribedTopics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	n := newLoopbackNode("o://services/weather", NodeTypeNode, nil)
+	if err := n.Start(ctx); err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+
+	events, err := n.On(n.Address(), "updated", func(ctx context.Context, event *Event) {})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	queries, err := n.ServeScatter(QueryTopic("forecast"))
+	if err != nil {
+		t.Fatalf("Failed to serve scatter: %v", err)
+	}
+	topics, err := n.topicCache()
+	if err != nil {
+		t.Fatalf("Failed to get topics: %v", err)
+	}
+	if joined := len(topics.Topics()); joined != 2 {
+		t.Fatalf("Expected 2 joined topics, got %d", joined)
+	}
+
+	// Topics only close once their subscriptions are cancelled
+	if err := n.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop with live subscriptions: %v", err)
+	}
+	if joined := len(topics.Topics()); joined != 0 {
+		t.Errorf("Expected every topic to be left, got %d", joined)
+	}
+	if _, err := n.topicCache(); err == nil {
+		t.Error("Expected no topics on a stopped node")
+	}
+
+	// Cancelling after Stop returns at once
+	events.Cancel()
+	queries.Cancel()
+}
+
+func TestEventValidator(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	emitter, _ := peer.IDFromPrivateKey(priv)
+
+	message := func(topic string, event *Event) *pubsub.Message {
+		data, _ := json.Marshal(event)
+		return &pubsub.Message{Message: &pb.Message{Topic: &topic, From: []byte(emitter), Data: data}}
+	}
+
+	validator := EventValidator(0, nil)
+	topic := EventTopic(NewOAddress("o://services/weather"), "updated")
+
+	valid := &Event{Address: "o://services/weather", Name: "updated", PeerID: emitter}
+	if err := validator.Schema(message(topic, valid)); err != nil {
+		t.Errorf("Expected valid event to pass, got %v", err)
+	}
+
+	spoofed := &Event{Address: "o://services/weather", Name: "updated", PeerID: "someone-else"}
+	if err := validator.Schema(message(topic, spoofed)); err == nil {
+		t.Error("Expected event naming another peer to be rejected")
+	}
+
+	misplaced := &Event{Address: "o://services/traffic", Name: "updated", PeerID: emitter}
+	if err := validator.Schema(message(topic, misplaced)); err == nil {
+		t.Error("Expected event from another address to be rejected")
+	}
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MethodHandler handles an inbound request for a single method.
+// The request's params hold only the caller's parameters: the reserved ones the
+// transport adds, such as ParamTargetAddress, are removed first.
+// Returning an *OError sends that error to the caller unchanged.
+type MethodHandler func(ctx context.Context, req *ORequest) (interface{}, error)
+
+// Handle registers a handler for a method on this node's address.
+// Methods without an OMethod description are listed in whoami by name only.
+func (n *CoreNode) Handle(method string, handler MethodHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[method] = handler
+	if _, ok := n.methods[method]; !ok {
+		n.methods[method] = &OMethod{Name: method}
+	}
+}
+
+// handler returns the handler registered for a method
+func (n *CoreNode) handler(method string) (MethodHandler, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	h, ok := n.handlers[method]
+	return h, ok
+}
+
+// protocolToAddress converts an o-protocol ID (/o/...) back to an o-address
+func protocolToAddress(proto protocol.ID) string {
+	return strings.Replace(string(proto), "/o/", "o://", 1)
+}
+
+// isOProtocol reports whether a protocol ID belongs to the o-protocol
+func isOProtocol(proto protocol.ID) bool {
+	return strings.HasPrefix(string(proto), "/o/")
+}
+
+// registerStreamHandlers installs the o-protocol stream handlers on the host.
+// Leaders accept every o-protocol so they can serve their child services and route
+// requests; other nodes only accept their own absolute and static addresses.
+// Every node accepts hellos and replies to its scattered requests, and leader
+// candidates accept election messages.
+func (n *CoreNode) registerStreamHandlers() {
+	n.p2pNode.SetStreamHandler(ScatterReplyProtocol, n.handleScatterReply)
+	n.p2pNode.SetStreamHandler(HelloProtocol, n.handleHello)
+
+	if n.Type() == NodeTypeLeader {
+		if n.election != nil {
+			n.p2pNode.SetStreamHandler(ElectionProtocol, n.election.handleStream)
+		}
+		n.p2pNode.SetStreamHandlerMatch(LeaderProtocol, isOProtocol, n.handleStream)
+		return
+	}
+
+	n.p2pNode.SetStreamHandler(protocol.ID(n.address.Protocol()), n.handleStream)
+	if !n.staticAddress.Equals(n.address) {
+		n.p2pNode.SetStreamHandler(protocol.ID(n.staticAddress.Protocol()), n.handleStream)
+	}
+}
+
+// handleStream serves a single o-protocol request on an inbound stream
+func (n *CoreNode) handleStream(s network.Stream) {
+	defer s.Close()
+
+	var req ORequest
+	if err := json.NewDecoder(s).Decode(&req); err != nil {
+		n.logger.Debugf("Failed to read request from %s: %v", s.Conn().RemotePeer(), err)
+		s.Reset()
+		return
+	}
+
+	// Only peers that completed the handshake with this node may call it
+	if err := n.admitRequest(s.Conn().RemotePeer()); err != nil {
+		n.logger.Debugf("Refused %s request from %s: %s", req.Method, s.Conn().RemotePeer(), err.Message)
+		if err := json.NewEncoder(s).Encode(NewOErrorResponse(req.ID, err.Code, err.Message, err.Data)); err != nil {
+			s.Reset()
+		}
+		return
+	}
+
+	if req.Params == nil {
+		req.Params = make(map[string]interface{})
+	}
+	if _, ok := req.Params[ParamTargetAddress].(string); !ok {
+		req.Params[ParamTargetAddress] = protocolToAddress(s.Protocol())
+	}
+
+	ctx := withRemotePeer(n.context(), s.Conn().RemotePeer())
+	response := n.dispatchRequest(ctx, &req)
+	if err := json.NewEncoder(s).Encode(response); err != nil {
+		n.logger.Debugf("Failed to write response to %s: %v", s.Conn().RemotePeer(), err)
+		s.Reset()
+	}
+}
+
+// remotePeerKey is the context key of the peer a request arrived from
+type remotePeerKey struct{}
+
+// withRemotePeer records the authenticated peer a request arrived from
+func withRemotePeer(ctx context.Context, p peer.ID) context.Context {
+	return context.WithValue(ctx, remotePeerKey{}, p)
+}
+
+// remotePeer returns the authenticated peer a request arrived from.
+// Requests dispatched without one came from this node itself.
+func (n *CoreNode) remotePeer(ctx context.Context) peer.ID {
+	if p, ok := ctx.Value(remotePeerKey{}).(peer.ID); ok {
+		return p
+	}
+	return n.peerId
+}
+
+// dispatchRequest executes a request addressed to this node, continuing the
+// caller's trace and recording its metrics
+func (n *CoreNode) dispatchRequest(ctx context.Context, req *ORequest) *OResponse {
+	ctx = extractTraceContext(ctx, req.Params)
+	ctx, span := n.tracer.Start(ctx, "olane.Dispatch",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrMethod.String(req.Method), attrLocalAddress.String(n.address.String())))
+	if caller, ok := req.Params[ParamCallerAddress].(string); ok {
+		span.SetAttributes(attrCaller.String(caller))
+	}
+
+	start := time.Now()
+	response := n.executeRequest(ctx, req)
+	n.metrics.observeInbound(req.Method, response, time.Since(start))
+	endSpan(span, response, nil)
+	return response
+}
+
+// executeRequest routes a request to the built-in or registered method handler.
+// Leaders forward requests for other addresses to the registered node serving them.
+func (n *CoreNode) executeRequest(ctx context.Context, req *ORequest) *OResponse {
+	if err := checkRequestProtocol(req); err != nil {
+		n.logger.Debugf("Rejected %s request: %v", req.Method, err)
+		return NewOErrorResponse(req.ID, err.Code, err.Message, err.Data)
+	}
+	target, _ := req.Params[ParamTargetAddress].(string)
+	if n.registry != nil && isRegistryAddress(target) {
+		return n.handleRegistryRequest(ctx, req)
+	}
+	if target != "" && target != n.address.String() && target != n.staticAddress.String() {
+		if n.registry != nil {
+			return n.forwardRequest(ctx, req, target)
+		}
+		return noRouteResponse(req.ID, target)
+	}
+
+	if req.Method == "whoami" {
+		whoami, err := n.WhoAmI(ctx)
+		if err != nil {
+			return errorResponse(req.ID, err)
+		}
+		return NewOResponse(req.ID, whoami)
+	}
+
+	handler, ok := n.handler(req.Method)
+	if !ok {
+		err := ErrMethodNotFound(req.Method)
+		return NewOErrorResponse(req.ID, err.Code, err.Message, err.Data)
+	}
+
+	// Handlers only see the caller's params, not the ones the transport reserves
+	call := *req
+	call.Params = userParams(req.Params)
+	result, err := handler(ctx, &call)
+	if err != nil {
+		return errorResponse(req.ID, err)
+	}
+	return NewOResponse(req.ID, result)
+}
+
+// forwardRequest relays a request for a registered node to that node and
+// returns its response, keeping the original caller and trace
+func (n *CoreNode) forwardRequest(ctx context.Context, req *ORequest, target string) *OResponse {
+	entry, routed, ok := n.registry.Route(target)
+	if !ok || entry.PeerID == n.peerId.String() {
+		return noRouteResponse(req.ID, target)
+	}
+	id, err := peer.Decode(entry.PeerID)
+	if err != nil {
+		return noRouteResponse(req.ID, target)
+	}
+
+	caller := n.address
+	if address, ok := req.Params[ParamCallerAddress].(string); ok && address != "" {
+		caller = NewOAddress(address)
+	}
+	connection, err := n.connectionManager.Connect(ctx, &ConnectionParams{
+		Address:        NewOAddress(routed),
+		NextHopAddress: peerAddress(entry.Address, id, entry.Transports),
+		CallerAddress:  caller,
+	})
+	if err != nil {
+		n.logger.Debugf("Failed to forward to %s: %v", target, err)
+		return errorResponse(req.ID, forwardError(target, err))
+	}
+	defer connection.Close()
+
+	response, err := connection.Send(ctx, &ConnectionSendParams{
+		Address: routed,
+		Payload: map[string]interface{}{
+			"method": req.Method,
+			"params": userParams(req.Params),
+		},
+	})
+	if err != nil {
+		n.logger.Debugf("Failed to forward to %s: %v", target, err)
+		return errorResponse(req.ID, forwardError(target, err))
+	}
+	response.ID = req.ID
+	return response
+}
+
+// forwardError keeps errors a node reported itself and wraps transport failures
+func forwardError(target string, err error) error {
+	var oErr *OError
+	if errors.As(err, &oErr) {
+		return oErr
+	}
+	return ErrConnectionFailed(target, err)
+}
+
+// noRouteResponse reports a target the node can neither serve nor forward
+func noRouteResponse(id, target string) *OResponse {
+	return NewOErrorResponse(id, ErrorCodeInvalidAddress, "no route to address: "+target, nil)
+}
+
+// errorResponse converts a handler error to an error response
+func errorResponse(id string, err error) *OResponse {
+	var oErr *OError
+	if errors.As(err, &oErr) {
+		return NewOErrorResponse(id, oErr.Code, oErr.Message, oErr.Data)
+	}
+	return NewOErrorResponse(id, ErrorCodeGeneral, err.Error(), nil)
+}
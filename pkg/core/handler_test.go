@@ -0,0 +1,165 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegistryRoute(t *testing.T) {
+	registry := NewRegistry()
+	calc := &RegistryEntry{PeerID: testPeerID(t, "calc"), Address: "o://leader/tools/calc", StaticAddress: "o://calc"}
+	tools := &RegistryEntry{PeerID: testPeerID(t, "tools"), Address: "o://leader/tools"}
+	for _, entry := range []*RegistryEntry{calc, tools} {
+		if err := registry.Commit(entry); err != nil {
+			t.Fatalf("Failed to commit %s: %v", entry.Address, err)
+		}
+	}
+
+	for target, expected := range map[string]string{
+		"o://leader/tools/calc":     "o://leader/tools/calc",
+		"o://leader/tools/calc/add": "o://leader/tools/calc/add",
+		"o://tools/calc":            "o://leader/tools/calc",
+		"o://calc/add":              "o://leader/tools/calc/add",
+		"o://leader/tools":          "o://leader/tools",
+		"o://leader/tools/weather":  "o://leader/tools/weather",
+		"o://tools/calculator":      "o://leader/tools/calculator",
+	} {
+		entry, routed, ok := registry.Route(target)
+		if !ok || routed != expected {
+			t.Errorf("Expected %s to route to %s, got %s", target, expected, routed)
+			continue
+		}
+		if owner := NewOAddress(routed); !owner.HasPrefix(entry.Address) {
+			t.Errorf("Expected %s to be routed to its owner, got %s", routed, entry.Address)
+		}
+	}
+	for _, target := range []string{"o://leader", "o://weather", "o://toolsets"} {
+		if entry, _, ok := registry.Route(target); ok {
+			t.Errorf("Expected %s not to route, got %s", target, entry.Address)
+		}
+	}
+}
+
+func TestLeaderForwardsRequests(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	tool := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	tool.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	tool.Handle("add", func(ctx context.Context, req *ORequest) (interface{}, error) {
+		a, _ := req.Params["a"].(float64)
+		b, _ := req.Params["b"].(float64)
+		reserved := []string{}
+		for name := range req.Params {
+			if isReservedParam(name) {
+				reserved = append(reserved, name)
+			}
+		}
+		return map[string]interface{}{"sum": a + b, "reserved": reserved}, nil
+	})
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	caller := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	caller.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	if err := caller.Start(ctx); err != nil {
+		t.Fatalf("Failed to start caller: %v", err)
+	}
+	defer caller.Stop(ctx)
+
+	for _, address := range []string{"o://leader/tools/calc", "o://tools/calc"} {
+		response, err := caller.Use(ctx, NewOAddress(address), "add", map[string]interface{}{"a": 2, "b": 3}, nil)
+		if err != nil {
+			t.Fatalf("Failed to use %s: %v", address, err)
+		}
+		if response.Error != nil {
+			t.Fatalf("Expected %s to succeed, got %v", address, response.Error)
+		}
+		result, _ := response.Result.(map[string]interface{})
+		if result["sum"] != 5.0 {
+			t.Errorf("Expected forwarded result, got %v", response.Result)
+		}
+		if reserved, _ := result["reserved"].([]interface{}); len(reserved) != 0 {
+			t.Errorf("Expected the handler not to see reserved params, got %v", reserved)
+		}
+	}
+
+	// Unregistered targets still have no route
+	response, err := caller.Use(ctx, NewOAddress("o://leader/tools/weather"), "forecast", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to use unregistered target: %v", err)
+	}
+	if response.Error == nil || response.Error.Code != ErrorCodeInvalidAddress {
+		t.Errorf("Expected no route error, got %+v", response.Error)
+	}
+}
+
+func TestRegistryWritesRequireTheirPeer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	tool := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	tool.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	intruder := newLoopbackNode("o://agents/intruder", NodeTypeAgent, nil)
+	if err := intruder.Start(ctx); err != nil {
+		t.Fatalf("Failed to start intruder: %v", err)
+	}
+	defer intruder.Stop(ctx)
+
+	registry := NewOAddress(RegistryAddress)
+	registry.SetTransports(dialAddress(t, leader).LibP2PTransports())
+	use := func(n *CoreNode, method string, params map[string]interface{}) *OResponse {
+		response, err := n.Use(ctx, registry, method, params, &UseOptions{NoIndex: true})
+		if err != nil {
+			t.Fatalf("Failed to call %s: %v", method, err)
+		}
+		return response
+	}
+
+	// A node writes its own entry
+	if response := use(tool, "heartbeat", map[string]interface{}{"peerId": tool.ID().String()}); response.Error != nil {
+		t.Errorf("Expected the tool to heartbeat its own entry, got %+v", response.Error)
+	}
+
+	// but not another node's, whatever peer ID it claims
+	victim := tool.ID().String()
+	for method, params := range map[string]map[string]interface{}{
+		"commit":    {"peerId": victim, "address": "o://leader/tools/calc", "transports": intruder.Transports()},
+		"heartbeat": {"peerId": victim},
+		"remove":    {"peerId": victim},
+	} {
+		if response := use(intruder, method, params); response.Error == nil || response.Error.Code != ErrorCodeUnauthorized {
+			t.Errorf("Expected %s of another peer's entry to be unauthorized, got %+v", method, response)
+		}
+	}
+	entry, ok := leader.Registry().Get(victim)
+	if !ok {
+		t.Fatal("Expected the tool to stay registered")
+	}
+	if len(entry.Transports) == 0 || entry.Transports[0] != tool.Transports()[0] {
+		t.Errorf("Expected the tool's entry to be unchanged, got %v", entry.Transports)
+	}
+	if allowed, _ := leader.Registry().AuthorizePeer(intruder.ID()); allowed {
+		t.Error("Expected the intruder not to be registered")
+	}
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// HeartbeatConfig controls how registered nodes report liveness to the leader
+// and how the leader checks on nodes that stop reporting
+type HeartbeatConfig struct {
+	// Interval between heartbeats, and between the leader's health checks
+	Interval time.Duration
+	// ProbeAfter is the number of missed heartbeats after which the leader probes the node with whoami
+	ProbeAfter int
+	// EvictAfter is the number of missed heartbeats after which the leader removes the node
+	EvictAfter int
+}
+
+// DefaultHeartbeatConfig returns the default heartbeat configuration
+func DefaultHeartbeatConfig() *HeartbeatConfig {
+	return &HeartbeatConfig{
+		Interval:   5 * time.Second,
+		ProbeAfter: 2,
+		EvictAfter: 5,
+	}
+}
+
+// withDefaults fills unset fields from DefaultHeartbeatConfig
+func (c *HeartbeatConfig) withDefaults() *HeartbeatConfig {
+	defaults := DefaultHeartbeatConfig()
+	if c == nil {
+		return defaults
+	}
+	merged := *c
+	if merged.Interval <= 0 {
+		merged.Interval = defaults.Interval
+	}
+	if merged.ProbeAfter <= 0 {
+		merged.ProbeAfter = defaults.ProbeAfter
+	}
+	if merged.EvictAfter <= merged.ProbeAfter {
+		merged.EvictAfter = merged.ProbeAfter + 1
+	}
+	return &merged
+}
+
+// healthLoop is the running heartbeat or health check loop of a node
+type healthLoop struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startHealth launches the health check loop on leaders, or the heartbeat
+// loop on nodes registered with a leader. It does nothing if a loop is running.
+func (n *CoreNode) startHealth() {
+	cfg := n.config.Heartbeat.withDefaults()
+
+	var loop func(context.Context, *HeartbeatConfig)
+	switch {
+	case n.registry != nil:
+		loop = n.checkRegistry
+	case n.Type() != NodeTypeLeader && n.Leader() != nil:
+		loop = n.sendHeartbeats
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithCancel(n.context())
+	h := &healthLoop{cancel: cancel, done: make(chan struct{})}
+	n.mu.Lock()
+	if n.health != nil {
+		n.mu.Unlock()
+		cancel()
+		return
+	}
+	n.health = h
+	n.mu.Unlock()
+
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				loop(ctx, cfg)
+			}
+		}
+	}()
+}
+
+// stopHealth ends the loop started by startHealth and waits for it to return
+func (n *CoreNode) stopHealth() {
+	n.mu.Lock()
+	h := n.health
+	n.health = nil
+	n.mu.Unlock()
+
+	if h != nil {
+		h.cancel()
+		<-h.done
+	}
+}
+
+// sendHeartbeats reports liveness to the leader, registering again if the
+// leader no longer knows this node
+func (n *CoreNode) sendHeartbeats(ctx context.Context, cfg *HeartbeatConfig) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Interval)
+	defer cancel()
+
+	params := map[string]interface{}{"peerId": n.peerId.String()}
+	response, err := n.Use(ctx, NewOAddress(RegistryStaticAddress), "heartbeat", params, &UseOptions{NoIndex: true})
+	if err != nil {
+		n.logger.Debugf("Failed to send heartbeat: %v", err)
+		return
+	}
+	if response.Error != nil && response.Error.Code == ErrorCodeNotRegistered {
+		n.logger.Info("Leader does not know this node, registering again")
+		if err := n.Register(ctx); err != nil {
+			n.logger.Warnf("Failed to register again: %v", err)
+		}
+	}
+}
+
+// checkRegistry probes registered nodes that missed heartbeats and evicts
+// nodes that have not been seen for too long. Only the elected leader checks.
+func (n *CoreNode) checkRegistry(ctx context.Context, cfg *HeartbeatConfig) {
+	if !n.IsLeader() {
+		return
+	}
+
+	now := time.Now()
+	probeBefore := now.Add(-time.Duration(cfg.ProbeAfter) * cfg.Interval).UnixMilli()
+	evictBefore := now.Add(-time.Duration(cfg.EvictAfter) * cfg.Interval).UnixMilli()
+
+	var wg sync.WaitGroup
+	for _, entry := range n.registry.FindAll() {
+		switch {
+		case entry.LastSeen < evictBefore:
+			if n.registry.Remove(entry.PeerID) {
+				n.logger.Infof("Evicted %s (%s) after missed heartbeats", entry.Address, entry.PeerID)
+			}
+		case entry.LastSeen < probeBefore:
+			wg.Add(1)
+			go func(entry *RegistryEntry) {
+				defer wg.Done()
+				n.probe(ctx, cfg, entry)
+			}(entry)
+		}
+	}
+	wg.Wait()
+}
+
+// probe calls whoami on a registered node, marking it seen if it answers and
+// unhealthy if it does not
+func (n *CoreNode) probe(ctx context.Context, cfg *HeartbeatConfig, entry *RegistryEntry) {
+	id, err := peer.Decode(entry.PeerID)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Interval)
+	defer cancel()
+
+	address := peerAddress(entry.Address, id, entry.Transports)
+	response, err := n.Use(ctx, address, "whoami", nil, &UseOptions{NoIndex: true})
+	if err == nil && response.Error == nil {
+		n.registry.Heartbeat(entry.PeerID)
+		return
+	}
+	if err == nil {
+		err = response.Error
+	}
+	if n.registry.SetHealth(entry.PeerID, RegistryHealthUnhealthy) {
+		n.logger.Debugf("Probe of %s (%s) failed: %v", entry.Address, entry.PeerID, err)
+	}
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitUntil polls cond until it holds, failing the test with what if ctx expires first
+func waitUntil(t *testing.T, ctx context.Context, what string, cond func() bool) {
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for %s", what)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestHeartbeatConfigDefaults(t *testing.T) {
+	var unset *HeartbeatConfig
+	if cfg := unset.withDefaults(); *cfg != *DefaultHeartbeatConfig() {
+		t.Errorf("Expected defaults for nil config, got %+v", cfg)
+	}
+
+	cfg := (&HeartbeatConfig{Interval: time.Second, ProbeAfter: 3, EvictAfter: 2}).withDefaults()
+	if cfg.Interval != time.Second || cfg.ProbeAfter != 3 || cfg.EvictAfter != 4 {
+		t.Errorf("Expected eviction to follow probing, got %+v", cfg)
+	}
+}
+
+func TestRegistryHeartbeatsAndEviction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	heartbeat := &HeartbeatConfig{Interval: 100 * time.Millisecond, ProbeAfter: 2, EvictAfter: 4}
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	leader.config.Heartbeat = heartbeat
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	tool := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	tool.config.Heartbeat = heartbeat
+	tool.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	registry := leader.Registry()
+	id := tool.ID().String()
+	waitUntil(t, ctx, "registration", func() bool { return registry.Len() == 1 })
+
+	// Heartbeats keep the entry fresh
+	registered, _ := registry.Get(id)
+	waitUntil(t, ctx, "a heartbeat", func() bool {
+		entry, ok := registry.Get(id)
+		return ok && entry.LastSeen > registered.LastSeen
+	})
+	results := registry.Search(&RegistryQuery{Health: RegistryHealthHealthy})
+	if len(results) != 1 || results[0].PeerID != id {
+		t.Errorf("Expected tool to be healthy, got %v", results)
+	}
+
+	// A node the leader forgot registers again on its next heartbeat
+	registry.Remove(id)
+	waitUntil(t, ctx, "registration after removal", func() bool { return registry.Len() == 1 })
+
+	// Without heartbeats the leader probes the node, which keeps it registered
+	tool.stopHealth()
+	probed, _ := registry.Get(id)
+	waitUntil(t, ctx, "a probe", func() bool {
+		entry, ok := registry.Get(id)
+		return ok && entry.LastSeen > probed.LastSeen+200
+	})
+	if entry, ok := registry.Get(id); !ok || entry.Health != RegistryHealthHealthy {
+		t.Errorf("Expected probed tool to stay healthy, got %+v", entry)
+	}
+
+	// A node that dies without unregistering is marked unhealthy, then evicted
+	if err := tool.Host().Close(); err != nil {
+		t.Fatalf("Failed to close tool host: %v", err)
+	}
+	waitUntil(t, ctx, "the tool to be unhealthy", func() bool {
+		entry, ok := registry.Get(id)
+		return !ok || entry.Health == RegistryHealthUnhealthy
+	})
+	waitUntil(t, ctx, "eviction", func() bool { return registry.Len() == 0 })
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	msmux "github.com/multiformats/go-multistream"
+)
+
+// HelloProtocol carries the handshake nodes exchange on their first connection
+const HelloProtocol = protocol.ID("/olane/hello/1.0.0")
+
+// helloTimeout bounds a handshake when the caller's context has no deadline
+const helloTimeout = 10 * time.Second
+
+// Feature is an optional protocol capability negotiated in the handshake
+type Feature string
+
+// Known features. They are only negotiated so far: no node sends partial
+// results or compressed frames yet, whatever the handshake agrees.
+const (
+	// FeatureStreaming will let a node send partial results before its final response
+	FeatureStreaming Feature = "streaming"
+	// FeatureCompression will let a node compress request and response frames
+	FeatureCompression Feature = "compression"
+)
+
+// Hello describes a node to the peer it connects to
+type Hello struct {
+	ProtocolVersion string    `json:"protocolVersion"`
+	Address         string    `json:"address"`
+	Type            NodeType  `json:"type"`
+	Features        []Feature `json:"features"`
+	NetworkName     string    `json:"networkName,omitempty"`
+}
+
+// helloReply answers a Hello with the responder's own, or the reason it was rejected
+type helloReply struct {
+	Hello *Hello  `json:"hello,omitempty"`
+	Error *OError `json:"error,omitempty"`
+}
+
+// Handshake is the outcome of the hello exchange with a peer
+type Handshake struct {
+	// ProtocolVersion is the version both sides speak, the lower of the two
+	ProtocolVersion string `json:"protocolVersion"`
+	// Features are the features both sides support
+	Features []Feature `json:"features"`
+	// Remote is the peer's hello
+	Remote *Hello `json:"remote,omitempty"`
+}
+
+// Supports reports whether both sides negotiated a feature
+func (h *Handshake) Supports(feature Feature) bool {
+	if h == nil {
+		return false
+	}
+	for _, f := range h.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate checks a peer's hello against the local one, rejecting peers of another
+// major protocol version or network and downgrading to what both sides support
+func negotiate(local, remote *Hello) (*Handshake, *OError) {
+	if remote == nil {
+		return nil, NewOError(ErrorCodeIncompatibleProtocol, "peer sent no hello", nil)
+	}
+	if err := CheckProtocolVersion(remote.ProtocolVersion); err != nil {
+		return nil, err
+	}
+	if local.NetworkName != "" && remote.NetworkName != "" && local.NetworkName != remote.NetworkName {
+		return nil, ErrNetworkMismatch(remote.NetworkName, local.NetworkName)
+	}
+
+	version := local.ProtocolVersion
+	if remote.ProtocolVersion != "" && compareProtocolVersions(remote.ProtocolVersion, version) < 0 {
+		version = remote.ProtocolVersion
+	}
+	features := []Feature{}
+	for _, f := range local.Features {
+		for _, r := range remote.Features {
+			if f == r {
+				features = append(features, f)
+				break
+			}
+		}
+	}
+	return &Handshake{ProtocolVersion: version, Features: features, Remote: remote}, nil
+}
+
+// compareProtocolVersions orders two versions, treating unparsable versions as equal
+func compareProtocolVersions(a, b string) int {
+	va, errA := parseProtocolVersion(a)
+	vb, errB := parseProtocolVersion(b)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// hello returns the hello this node sends to its peers
+func (n *CoreNode) hello() *Hello {
+	features := append([]Feature{}, n.config.Features...)
+	return &Hello{
+		ProtocolVersion: ProtocolVersion,
+		Address:         n.address.String(),
+		Type:            n.Type(),
+		Features:        features,
+		NetworkName:     n.config.NetworkName,
+	}
+}
+
+// handleHello answers a peer's hello, rejecting peers it cannot talk to.
+// Accepted hellos are recorded before the reply, so the peer's requests that
+// follow it are admitted.
+func (n *CoreNode) handleHello(s network.Stream) {
+	defer s.Close()
+	_ = s.SetDeadline(time.Now().Add(helloTimeout))
+
+	var remote Hello
+	if err := json.NewDecoder(s).Decode(&remote); err != nil {
+		n.logger.Debugf("Failed to read hello from %s: %v", s.Conn().RemotePeer(), err)
+		s.Reset()
+		return
+	}
+
+	reply := &helloReply{Hello: n.hello()}
+	if _, err := negotiate(reply.Hello, &remote); err != nil {
+		n.logger.Warnf("Rejected hello from %s (%s): %s", s.Conn().RemotePeer(), remote.Address, err.Message)
+		n.setHello(s.Conn().RemotePeer(), nil)
+		reply = &helloReply{Error: err}
+	} else {
+		n.setHello(s.Conn().RemotePeer(), &remote)
+	}
+	if err := json.NewEncoder(s).Encode(reply); err != nil {
+		n.logger.Debugf("Failed to write hello to %s: %v", s.Conn().RemotePeer(), err)
+		s.Reset()
+	}
+}
+
+// admitRequest checks that a peer sent this node a hello it accepted before
+// serving the peer's o-protocol requests
+func (n *CoreNode) admitRequest(p peer.ID) *OError {
+	n.mu.RLock()
+	remote := n.hellos[p]
+	n.mu.RUnlock()
+	_, err := negotiate(n.hello(), remote)
+	return err
+}
+
+// admitPeer admits a peer's requests like admitRequest, first saying hello to
+// the peer if it has not said hello to this node. Scattered requests arrive over
+// pubsub, so their requester may never have dialed this node.
+func (n *CoreNode) admitPeer(ctx context.Context, h host.Host, p peer.ID) error {
+	if err := n.admitRequest(p); err == nil {
+		return nil
+	}
+	handshake, err := sayHello(ctx, h, p, n.hello())
+	if err != nil {
+		return err
+	}
+	n.setHello(p, handshake.Remote)
+	return nil
+}
+
+// setHello records the hello accepted from a peer, or forgets it if hello is nil
+func (n *CoreNode) setHello(p peer.ID, hello *Hello) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if hello == nil {
+		delete(n.hellos, p)
+		return
+	}
+	n.hellos[p] = hello
+}
+
+// peerDisconnected forgets a peer's hello once its last connection closes, so
+// the peer has to say hello again after reconnecting
+func (n *CoreNode) peerDisconnected(net network.Network, c network.Conn) {
+	if net.Connectedness(c.RemotePeer()) == network.Connected {
+		return
+	}
+	n.setHello(c.RemotePeer(), nil)
+}
+
+// handshake exchanges hellos with a peer. Peers that do not speak HelloProtocol
+// are refused, as they would refuse our requests; they, peers that reject our
+// hello and peers whose hello we reject return the *OError describing why.
+func (m *StreamConnectionManager) handshake(ctx context.Context, id peer.ID) (*Handshake, error) {
+	return sayHello(ctx, m.host, id, m.localHello())
+}
+
+// sayHello sends local to a peer over HelloProtocol and negotiates with its reply
+func sayHello(ctx context.Context, h host.Host, id peer.ID, local *Hello) (*Handshake, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, helloTimeout)
+		defer cancel()
+	}
+
+	s, err := h.NewStream(ctx, id, HelloProtocol)
+	if err != nil {
+		if errors.Is(err, msmux.ErrNotSupported[protocol.ID]{}) {
+			return nil, NewOError(ErrorCodeIncompatibleProtocol, "peer does not support "+string(HelloProtocol), nil)
+		}
+		return nil, fmt.Errorf("failed to open hello stream to %s: %w", id, err)
+	}
+	defer s.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(s).Encode(local); err != nil {
+		s.Reset()
+		return nil, fmt.Errorf("failed to write hello: %w", err)
+	}
+	if err := s.CloseWrite(); err != nil {
+		s.Reset()
+		return nil, fmt.Errorf("failed to close hello stream: %w", err)
+	}
+
+	var reply helloReply
+	if err := json.NewDecoder(s).Decode(&reply); err != nil {
+		s.Reset()
+		return nil, fmt.Errorf("failed to read hello: %w", err)
+	}
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+	handshake, oErr := negotiate(local, reply.Hello)
+	if oErr != nil {
+		return nil, oErr
+	}
+	return handshake, nil
+}
+
+// localHello returns the hello to send, or a bare one if the manager has no node
+func (m *StreamConnectionManager) localHello() *Hello {
+	if m.hello != nil {
+		return m.hello()
+	}
+	return &Hello{ProtocolVersion: ProtocolVersion, Type: NodeTypeUnknown, Features: []Feature{}}
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+func TestNegotiate(t *testing.T) {
+	local := &Hello{ProtocolVersion: "1.2.0", Features: []Feature{FeatureStreaming, FeatureCompression}, NetworkName: "olane"}
+
+	handshake, err := negotiate(local, &Hello{ProtocolVersion: "1.0.3", Features: []Feature{FeatureCompression, "telepathy"}})
+	if err != nil {
+		t.Fatalf("Expected compatible peers, got %v", err)
+	}
+	if handshake.ProtocolVersion != "1.0.3" {
+		t.Errorf("Expected downgrade to 1.0.3, got %s", handshake.ProtocolVersion)
+	}
+	if len(handshake.Features) != 1 || !handshake.Supports(FeatureCompression) || handshake.Supports(FeatureStreaming) {
+		t.Errorf("Expected only compression, got %v", handshake.Features)
+	}
+
+	if _, err := negotiate(local, &Hello{ProtocolVersion: "2.0.0"}); err == nil || err.Code != ErrorCodeIncompatibleProtocol {
+		t.Errorf("Expected incompatible protocol error, got %v", err)
+	}
+	if _, err := negotiate(local, &Hello{ProtocolVersion: "1.0.0", NetworkName: "other"}); err == nil || err.Code != ErrorCodeNetworkMismatch {
+		t.Errorf("Expected network mismatch error, got %v", err)
+	}
+	if _, err := negotiate(local, &Hello{ProtocolVersion: "1.0.0"}); err != nil {
+		t.Errorf("Expected a peer without a network name to be accepted, got %v", err)
+	}
+
+	var none *Handshake
+	if none.Supports(FeatureStreaming) {
+		t.Error("Expected a nil handshake to support nothing")
+	}
+}
+
+func TestHelloOnConnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	server := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	server.config.Features = []Feature{FeatureStreaming, FeatureCompression}
+	server.config.NetworkName = "olane"
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop(ctx)
+
+	client := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	client.config.Features = []Feature{FeatureStreaming}
+	client.config.NetworkName = "olane"
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer client.Stop(ctx)
+
+	target := dialAddress(t, server)
+	conn, err := client.Connect(ctx, target, target)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	handshake := conn.Handshake()
+	if handshake == nil || handshake.Remote == nil {
+		t.Fatalf("Expected handshake with the server's hello, got %+v", handshake)
+	}
+	if handshake.Remote.Type != NodeTypeTool || handshake.Remote.Address != "o://leader/tools/calc" {
+		t.Errorf("Expected the server's hello, got %+v", handshake.Remote)
+	}
+	if !handshake.Supports(FeatureStreaming) || handshake.Supports(FeatureCompression) {
+		t.Errorf("Expected only streaming, got %v", handshake.Features)
+	}
+	if response, err := conn.Send(ctx, &ConnectionSendParams{Address: target.String(), Payload: map[string]interface{}{"method": "whoami"}}); err != nil || response.Error != nil {
+		t.Errorf("Expected whoami to succeed after the handshake, got %v (%v)", response, err)
+	}
+
+	// Later connections reuse the handshake
+	again, err := client.Connect(ctx, target, target)
+	if err != nil || again.Handshake() != handshake {
+		t.Errorf("Expected the first handshake to be reused, got %+v (%v)", again, err)
+	}
+
+	// Nodes on another network are rejected
+	stranger := newLoopbackNode("o://agents/stranger", NodeTypeAgent, nil)
+	stranger.config.NetworkName = "elsewhere"
+	if err := stranger.Start(ctx); err != nil {
+		t.Fatalf("Failed to start stranger: %v", err)
+	}
+	defer stranger.Stop(ctx)
+	_, err = stranger.Connect(ctx, target, target)
+	var oErr *OError
+	if !errors.As(err, &oErr) || oErr.Code != ErrorCodeNetworkMismatch {
+		t.Errorf("Expected network mismatch error, got %v", err)
+	}
+
+	// Peers that predate the handshake are refused, as they refuse our requests
+	server.Host().RemoveStreamHandler(HelloProtocol)
+	legacy := newLoopbackNode("o://agents/legacy", NodeTypeAgent, nil)
+	if err := legacy.Start(ctx); err != nil {
+		t.Fatalf("Failed to start legacy client: %v", err)
+	}
+	defer legacy.Stop(ctx)
+	_, err = legacy.Connect(ctx, target, target)
+	if !errors.As(err, &oErr) || oErr.Code != ErrorCodeIncompatibleProtocol {
+		t.Errorf("Expected incompatible protocol error without a handshake, got %v", err)
+	}
+}
+
+// rawRequest sends a whoami request to a node's o-protocol without the handshake
+func rawRequest(t *testing.T, ctx context.Context, from, to *CoreNode) *OResponse {
+	if err := from.Host().Connect(ctx, peer.AddrInfo{ID: to.ID(), Addrs: to.Host().Addrs()}); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	s, err := from.Host().NewStream(ctx, to.ID(), protocol.ID(to.Address().Protocol()))
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer s.Close()
+	if err := json.NewEncoder(s).Encode(NewORequest("1", "whoami", nil)); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+	var response OResponse
+	if err := json.NewDecoder(s).Decode(&response); err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return &response
+}
+
+func TestInboundRequestsRequireHello(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	server := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	server.config.NetworkName = "olane"
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop(ctx)
+
+	client := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	client.config.NetworkName = "olane"
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer client.Stop(ctx)
+
+	// Requests before the handshake are refused
+	if response := rawRequest(t, ctx, client, server); response.Error == nil || response.Error.Code != ErrorCodeIncompatibleProtocol {
+		t.Errorf("Expected a request without a hello to be refused, got %+v", response)
+	}
+
+	target := dialAddress(t, server)
+	if _, err := client.Connect(ctx, target, target); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if response := rawRequest(t, ctx, client, server); response.Error != nil {
+		t.Errorf("Expected requests after the handshake to be served, got %+v", response.Error)
+	}
+
+	// A peer whose hello was rejected cannot call the node directly either
+	stranger := newLoopbackNode("o://agents/stranger", NodeTypeAgent, nil)
+	stranger.config.NetworkName = "elsewhere"
+	if err := stranger.Start(ctx); err != nil {
+		t.Fatalf("Failed to start stranger: %v", err)
+	}
+	defer stranger.Stop(ctx)
+	if _, err := stranger.Connect(ctx, target, target); err == nil {
+		t.Fatal("Expected the stranger's hello to be rejected")
+	}
+	if response := rawRequest(t, ctx, stranger, server); response.Error == nil || response.Error.Code != ErrorCodeIncompatibleProtocol {
+		t.Errorf("Expected the stranger's request to be refused, got %+v", response)
+	}
+}
+
+func TestDisconnectForgetsPeer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	server := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop(ctx)
+
+	client := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+	defer client.Stop(ctx)
+
+	target := dialAddress(t, server)
+	if _, err := client.Connect(ctx, target, target); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if _, ok := client.connectionManager.GetConnection(server.ID()); !ok {
+		t.Fatal("Expected the client to track its connection to the server")
+	}
+	if err := server.admitRequest(client.ID()); err != nil {
+		t.Fatalf("Expected the server to admit the client after its hello, got %v", err)
+	}
+
+	if err := client.Host().Network().ClosePeer(server.ID()); err != nil {
+		t.Fatalf("Failed to disconnect: %v", err)
+	}
+	waitUntil(t, ctx, "the server to forget the client's hello", func() bool {
+		return server.admitRequest(client.ID()) != nil
+	})
+	waitUntil(t, ctx, "the client to forget its connection", func() bool {
+		_, ok := client.connectionManager.GetConnection(server.ID())
+		return !ok
+	})
+
+	// Reconnecting says hello again
+	if _, err := client.Connect(ctx, target, target); err != nil {
+		t.Fatalf("Failed to reconnect: %v", err)
+	}
+	if err := server.admitRequest(client.ID()); err != nil {
+		t.Errorf("Expected the server to admit the client after reconnecting, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultMetricsAddress is the local HTTP address metrics are served on by default,
+// so Prometheus can scrape http://127.0.0.1:9473/metrics. Nodes sharing a host
+// need their own CoreConfig.MetricsAddress; a port of 0 lets the OS pick one.
+const DefaultMetricsAddress = "127.0.0.1:9473"
+
+// metricsNamespace prefixes every metric exported by a node
+const metricsNamespace = "olane"
+
+// Metric result codes for calls that did not return an OError code
+const (
+	metricsCodeOK    = "ok"
+	metricsCodeError = "error"
+)
+
+// Metrics holds the Prometheus collectors for a node.
+// All methods are safe to call on a nil *Metrics, which records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+	server   *http.Server
+	address  string
+
+	useRequests      *prometheus.CounterVec
+	useDuration      *prometheus.HistogramVec
+	inboundRequests  *prometheus.CounterVec
+	inboundDuration  *prometheus.HistogramVec
+	registryEntries  prometheus.Gauge
+	dhtDuration      *prometheus.HistogramVec
+	stateTransitions *prometheus.CounterVec
+}
+
+// NewMetrics creates the node metrics on a fresh registry.
+// peers and conns report the current connected peer and connection counts.
+func NewMetrics(peers, conns func() int) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		useRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "use_requests_total",
+			Help:      "Outbound Use calls by target address, method and result code.",
+		}, []string{"address", "method", "code"}),
+		useDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "use_duration_seconds",
+			Help:      "Latency of outbound Use calls by target address and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"address", "method"}),
+		inboundRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "inbound_requests_total",
+			Help:      "Inbound requests handled by method and result code.",
+		}, []string{"method", "code"}),
+		inboundDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "inbound_duration_seconds",
+			Help:      "Latency of inbound request handlers by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		registryEntries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "registry_entries",
+			Help:      "Number of nodes in the leader registry.",
+		}),
+		dhtDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "dht_operation_duration_seconds",
+			Help:      "Duration of DHT operations by operation and result.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"operation", "result"}),
+		stateTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "state_transitions_total",
+			Help:      "Node state transitions.",
+		}, []string{"from", "to"}),
+	}
+
+	m.registry.MustRegister(
+		m.useRequests,
+		m.useDuration,
+		m.inboundRequests,
+		m.inboundDuration,
+		m.registryEntries,
+		m.dhtDuration,
+		m.stateTransitions,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "connected_peers",
+			Help:      "Number of currently connected peers.",
+		}, func() float64 { return float64(peers()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "open_connections",
+			Help:      "Number of currently open connections.",
+		}, func() float64 { return float64(conns()) }),
+	)
+
+	return m
+}
+
+// Registry returns the Prometheus registry holding the node's metrics.
+// The libp2p host registers its own metrics, including the resource manager's, here too.
+func (m *Metrics) Registry() *prometheus.Registry {
+	if m == nil {
+		return nil
+	}
+	return m.registry
+}
+
+// resultCode returns the metric code label for a response and error
+func resultCode(response *OResponse, err error) string {
+	if err != nil {
+		var oErr *OError
+		if errors.As(err, &oErr) {
+			return strconv.Itoa(oErr.Code)
+		}
+		return metricsCodeError
+	}
+	if response != nil && response.Error != nil {
+		return strconv.Itoa(response.Error.Code)
+	}
+	return metricsCodeOK
+}
+
+// observeUse records an outbound Use call
+func (m *Metrics) observeUse(address, method string, response *OResponse, err error, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.useRequests.WithLabelValues(address, method, resultCode(response, err)).Inc()
+	m.useDuration.WithLabelValues(address, method).Observe(duration.Seconds())
+}
+
+// observeInbound records an inbound request
+func (m *Metrics) observeInbound(method string, response *OResponse, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.inboundRequests.WithLabelValues(method, resultCode(response, nil)).Inc()
+	m.inboundDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// observeDHT records the duration of a DHT operation
+func (m *Metrics) observeDHT(operation string, err error, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	result := metricsCodeOK
+	if err != nil {
+		result = metricsCodeError
+	}
+	m.dhtDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// observeStateTransition records a node state change
+func (m *Metrics) observeStateTransition(from, to NodeState) {
+	if m == nil || from == to {
+		return
+	}
+	m.stateTransitions.WithLabelValues(string(from), string(to)).Inc()
+}
+
+// SetRegistrySize records the number of entries in the leader registry
+func (m *Metrics) SetRegistrySize(size int) {
+	if m == nil {
+		return
+	}
+	m.registryEntries.Set(float64(size))
+}
+
+// Serve starts serving the metrics on addr at /metrics and returns the bound address
+func (m *Metrics) Serve(addr string) (string, error) {
+	if m == nil {
+		return "", fmt.Errorf("metrics are not enabled")
+	}
+	if addr == "" {
+		addr = DefaultMetricsAddress
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen for metrics on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	m.address = listener.Addr().String()
+
+	go func() {
+		_ = m.server.Serve(listener)
+	}()
+
+	return m.address, nil
+}
+
+// Address returns the address metrics are served on, or "" if they are not being served
+func (m *Metrics) Address() string {
+	if m == nil || m.server == nil {
+		return ""
+	}
+	return m.address
+}
+
+// Shutdown stops the metrics HTTP server
+func (m *Metrics) Shutdown(ctx context.Context) error {
+	if m == nil || m.server == nil {
+		return nil
+	}
+	err := m.server.Shutdown(ctx)
+	m.server = nil
+	m.address = ""
+	return err
+}
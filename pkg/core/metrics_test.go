@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+)
+
+// scrapeMetrics fetches a node's metrics endpoint
+func scrapeMetrics(t *testing.T, n *CoreNode) string {
+	resp, err := http.Get("http://" + n.Metrics().Address() + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+// newMetricsNode creates a loopback node serving metrics on an OS-assigned port
+func newMetricsNode(address string, nodeType NodeType) *CoreNode {
+	cfg := loopbackConfig(address, nodeType, nil)
+	cfg.Metrics = true
+	cfg.MetricsAddress = "127.0.0.1:0"
+	return NewCoreNode(cfg)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newMetricsNode("o://leader", NodeTypeLeader)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	tool := newMetricsNode("o://leader/tools/calc", NodeTypeTool)
+	tool.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	tool.Handle("add", func(ctx context.Context, req *ORequest) (interface{}, error) {
+		return 3, nil
+	})
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	caller := newMetricsNode("o://agents/planner", NodeTypeAgent)
+	caller.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	if err := caller.Start(ctx); err != nil {
+		t.Fatalf("Failed to start caller: %v", err)
+	}
+	defer caller.Stop(ctx)
+
+	for _, n := range []*CoreNode{leader, tool, caller} {
+		if n.Metrics().Address() == "" {
+			t.Fatalf("Expected %s to serve metrics", n.Address())
+		}
+	}
+
+	if _, err := caller.Use(ctx, NewOAddress("o://leader/tools/calc"), "add", nil, nil); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		node   *CoreNode
+		series string
+	}{
+		{caller, `olane_use_requests_total{address="o://leader/tools/calc",code="ok",method="add"} 1`},
+		{caller, `olane_use_duration_seconds_count{address="o://leader/tools/calc",method="add"} 1`},
+		{tool, `olane_inbound_requests_total{code="ok",method="add"} 1`},
+		{tool, `olane_inbound_duration_seconds_count{method="add"} 1`},
+		{leader, `olane_registry_entries 2`},
+		{leader, `olane_state_transitions_total{from="STARTING",to="RUNNING"} 1`},
+		{caller, `olane_connected_peers`},
+		{caller, `libp2p_rcmgr_`},
+	} {
+		if body := scrapeMetrics(t, tt.node); !strings.Contains(body, tt.series) {
+			t.Errorf("Expected %s metrics to contain %s", tt.node.Address(), tt.series)
+		}
+	}
+}
+
+func TestMetricsWithResourceLimits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newMetricsNode("o://leader", NodeTypeLeader)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	// A custom resource manager reports to Prometheus like the default one
+	caller := newMetricsNode("o://agents/planner", NodeTypeAgent)
+	caller.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	caller.networkConfig.ResourceLimits = config.NewResourceLimits(config.ResourcePresetSmall)
+	if err := caller.Start(ctx); err != nil {
+		t.Fatalf("Failed to start caller: %v", err)
+	}
+	defer caller.Stop(ctx)
+
+	if _, err := caller.Use(ctx, NewOAddress("o://leader"), "whoami", nil, nil); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	series := `libp2p_rcmgr_streams{dir="outbound",protocol="",scope="system"}`
+	if body := scrapeMetrics(t, caller); !strings.Contains(body, series) {
+		t.Errorf("Expected metrics to contain %s", series)
+	}
+}
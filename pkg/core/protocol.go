@@ -0,0 +1,51 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParamProtocolVersion carries the caller's o-protocol version in request params
+const ParamProtocolVersion = "_protocolVersion"
+
+// parseProtocolVersion parses a major.minor.patch version; minor and patch may be omitted
+func parseProtocolVersion(version string) ([3]int, error) {
+	var parsed [3]int
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) > 3 {
+		return parsed, fmt.Errorf("invalid protocol version %q", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid protocol version %q", version)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+// CheckProtocolVersion returns an ErrIncompatibleProtocol error unless a peer
+// speaking version can talk to this node. Versions are compatible when their
+// major versions match; an empty version is a peer that predates negotiation.
+func CheckProtocolVersion(version string) *OError {
+	if version == "" {
+		return nil
+	}
+	remote, err := parseProtocolVersion(version)
+	if err != nil {
+		return ErrIncompatibleProtocol(version)
+	}
+	local, _ := parseProtocolVersion(ProtocolVersion)
+	if remote[0] != local[0] {
+		return ErrIncompatibleProtocol(version)
+	}
+	return nil
+}
+
+// checkRequestProtocol checks the o-protocol version of an inbound request
+func checkRequestProtocol(req *ORequest) *OError {
+	version, _ := req.Params[ParamProtocolVersion].(string)
+	return CheckProtocolVersion(version)
+}
@@ -0,0 +1,670 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Registry addresses served by leaders
+const (
+	// RegistryAddress is the absolute address of the leader registry
+	RegistryAddress = "o://leader/register"
+	// RegistryStaticAddress is the static address nodes register through
+	RegistryStaticAddress = "o://register"
+)
+
+// RegistryEntry describes a node registered with the leader
+type RegistryEntry struct {
+	PeerID        string   `json:"peerId"`
+	Address       string   `json:"address"`
+	StaticAddress string   `json:"staticAddress,omitempty"`
+	Protocols     []string `json:"protocols"`
+	Transports    []string `json:"transports"`
+	// Capabilities the node advertises for search
+	Type        NodeType            `json:"type,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Methods     map[string]*OMethod `json:"methods,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	// RegisteredAt is when the node last committed its entry, in Unix milliseconds
+	RegisteredAt int64 `json:"registeredAt"`
+	// LastSeen is when the node last heartbeat or answered a probe, in Unix milliseconds
+	LastSeen int64          `json:"lastSeen"`
+	Health   RegistryHealth `json:"health"`
+}
+
+// RegistryHealth is the leader's view of whether a registered node is reachable
+type RegistryHealth string
+
+// Registry health values
+const (
+	// RegistryHealthHealthy nodes are heartbeating or answered the last probe
+	RegistryHealthHealthy RegistryHealth = "healthy"
+	// RegistryHealthUnhealthy nodes missed heartbeats and did not answer a probe
+	RegistryHealthUnhealthy RegistryHealth = "unhealthy"
+)
+
+// clone returns a copy of the entry that shares no slices with it
+func (e *RegistryEntry) clone() *RegistryEntry {
+	c := *e
+	c.Protocols = append([]string(nil), e.Protocols...)
+	c.Transports = append([]string(nil), e.Transports...)
+	c.Tags = append([]string(nil), e.Tags...)
+	if e.Methods != nil {
+		c.Methods = make(map[string]*OMethod, len(e.Methods))
+		for name, method := range e.Methods {
+			c.Methods[name] = method
+		}
+	}
+	return &c
+}
+
+// RegistryQuery selects registry entries; empty fields match every entry
+type RegistryQuery struct {
+	StaticAddress string
+	Address       string
+	Protocol      string
+	Health        RegistryHealth
+}
+
+// matches reports whether an entry satisfies the query
+func (q *RegistryQuery) matches(e *RegistryEntry) bool {
+	if q.StaticAddress != "" && e.StaticAddress != q.StaticAddress {
+		return false
+	}
+	if q.Address != "" && e.Address != q.Address {
+		return false
+	}
+	if q.Health != "" && e.Health != q.Health {
+		return false
+	}
+	if q.Protocol != "" {
+		for _, p := range e.Protocols {
+			if p == q.Protocol {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// RegistrySnapshot is a point-in-time copy of a registry, used for replication
+type RegistrySnapshot struct {
+	Version uint64           `json:"version"`
+	Entries []*RegistryEntry `json:"entries"`
+	Aliases []*Alias         `json:"aliases,omitempty"`
+}
+
+// Registry stores the nodes registered with a leader, keyed by peer ID.
+// Every change increments its version so replicas can tell when they are behind,
+// except liveness: heartbeats only refresh LastSeen, which is not replicated.
+type Registry struct {
+	entries map[string]*RegistryEntry
+	aliases map[string]*Alias
+	version uint64
+	index   *searchIndex
+	onSize  func(int)
+	// onChange is notified, on its own goroutine, of commits, removals and alias changes
+	onChange func(*RegistryChange)
+	mu       sync.RWMutex
+}
+
+// NewRegistry creates an empty registry with keyword search
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: make(map[string]*RegistryEntry),
+		aliases: make(map[string]*Alias),
+		index:   newSearchIndex(),
+	}
+}
+
+// SetEmbedder enables semantic search with embedder, storing embeddings in
+// vectors (nil uses a MemoryVectorIndex). A nil embedder disables semantic search.
+func (r *Registry) SetEmbedder(embedder Embedder, vectors VectorIndex) {
+	if embedder != nil && vectors == nil {
+		vectors = NewMemoryVectorIndex()
+	}
+	r.index.setEmbedder(embedder, vectors)
+}
+
+// changed bumps the version and reports the new size; callers hold the write lock
+func (r *Registry) changed() {
+	r.version++
+	if r.onSize != nil {
+		r.onSize(len(r.entries))
+	}
+}
+
+// notify reports a change to onChange without blocking the caller
+func (r *Registry) notify(change *RegistryChange) {
+	if r.onChange != nil {
+		go r.onChange(change)
+	}
+}
+
+// Commit adds or replaces the entry for a peer
+func (r *Registry) Commit(entry *RegistryEntry) error {
+	if entry.PeerID == "" {
+		return fmt.Errorf("registry entry requires a peer ID")
+	}
+	if _, err := peer.Decode(entry.PeerID); err != nil {
+		return fmt.Errorf("invalid peer ID %s: %w", entry.PeerID, err)
+	}
+	if entry.Address == "" {
+		return fmt.Errorf("registry entry requires an address")
+	}
+
+	entry = entry.clone()
+	now := time.Now().UnixMilli()
+	if entry.RegisteredAt == 0 {
+		entry.RegisteredAt = now
+	}
+	if entry.LastSeen == 0 {
+		entry.LastSeen = entry.RegisteredAt
+	}
+	if entry.Health == "" {
+		entry.Health = RegistryHealthHealthy
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[entry.PeerID] = entry
+	r.index.put(entry)
+	r.changed()
+	r.notify(&RegistryChange{Address: entry.Address, StaticAddress: entry.StaticAddress})
+	return nil
+}
+
+// Remove deletes the entry for a peer, reporting whether it existed
+func (r *Registry) Remove(peerID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[peerID]
+	if !ok {
+		return false
+	}
+	delete(r.entries, peerID)
+	r.index.remove(peerID)
+	r.changed()
+	r.notify(&RegistryChange{Address: entry.Address, StaticAddress: entry.StaticAddress})
+	return true
+}
+
+// Heartbeat marks a peer healthy and seen now, reporting whether it is registered.
+// Only a change of health bumps the version.
+func (r *Registry) Heartbeat(peerID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[peerID]
+	if !ok {
+		return false
+	}
+	entry.LastSeen = time.Now().UnixMilli()
+	if entry.Health != RegistryHealthHealthy {
+		entry.Health = RegistryHealthHealthy
+		r.changed()
+	}
+	return true
+}
+
+// touchAll marks every peer seen now without bumping the version. Replicas do
+// not track liveness, so a new leader gives every node a full eviction window.
+func (r *Registry) touchAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UnixMilli()
+	for _, entry := range r.entries {
+		entry.LastSeen = now
+	}
+}
+
+// SetHealth updates the health of a peer, reporting whether it is registered
+func (r *Registry) SetHealth(peerID string, health RegistryHealth) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[peerID]
+	if !ok {
+		return false
+	}
+	if entry.Health != health {
+		entry.Health = health
+		r.changed()
+	}
+	return true
+}
+
+// Get returns the entry for a peer
+func (r *Registry) Get(peerID string) (*RegistryEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.entries[peerID]
+	if !ok {
+		return nil, false
+	}
+	return entry.clone(), true
+}
+
+// Search returns the entries matching query, ordered by address
+func (r *Registry) Search(query *RegistryQuery) []*RegistryEntry {
+	if query == nil {
+		query = &RegistryQuery{}
+	}
+
+	r.mu.RLock()
+	result := make([]*RegistryEntry, 0)
+	for _, entry := range r.entries {
+		if query.matches(entry) {
+			result = append(result, entry.clone())
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Address != result[j].Address {
+			return result[i].Address < result[j].Address
+		}
+		return result[i].PeerID < result[j].PeerID
+	})
+	return result
+}
+
+// Route returns the entry a leader forwards a target address to, and the target
+// rewritten onto that entry's address. The entry is the one whose address or
+// static address is the longest prefix of the target; nodes under the leader
+// (o://leader/tools/calc) also match their leader-relative form (o://tools/calc).
+func (r *Registry) Route(target string) (*RegistryEntry, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best *RegistryEntry
+	bestPrefix := ""
+	for _, entry := range r.entries {
+		for _, candidate := range routeCandidates(entry) {
+			if len(candidate) > len(bestPrefix) && (target == candidate || strings.HasPrefix(target, candidate+"/")) {
+				best, bestPrefix = entry, candidate
+			}
+		}
+	}
+	if best == nil {
+		return nil, "", false
+	}
+	return best.clone(), best.Address + strings.TrimPrefix(target, bestPrefix), true
+}
+
+// routeCandidates returns the addresses a registered node can be reached by
+func routeCandidates(e *RegistryEntry) []string {
+	candidates := []string{e.Address}
+	if e.StaticAddress != "" && e.StaticAddress != e.Address {
+		candidates = append(candidates, e.StaticAddress)
+	}
+	if relative := strings.TrimPrefix(e.Address, "o://leader/"); relative != e.Address {
+		candidates = append(candidates, "o://"+relative)
+	}
+	return candidates
+}
+
+// FindAll returns every entry, ordered by address
+func (r *Registry) FindAll() []*RegistryEntry {
+	return r.Search(nil)
+}
+
+// Len returns the number of entries
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries)
+}
+
+// Version returns the number of changes applied to the registry
+func (r *Registry) Version() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// Snapshot returns a copy of the registry and its version
+func (r *Registry) Snapshot() *RegistrySnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]*RegistryEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry.clone())
+	}
+	aliases := make([]*Alias, 0, len(r.aliases))
+	for _, alias := range r.aliases {
+		aliases = append(aliases, alias.clone())
+	}
+	return &RegistrySnapshot{Version: r.version, Entries: entries, Aliases: aliases}
+}
+
+// Restore replaces the registry's contents and version with a snapshot
+func (r *Registry) Restore(snapshot *RegistrySnapshot) {
+	entries := make(map[string]*RegistryEntry, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		entries[entry.PeerID] = entry.clone()
+	}
+	aliases := make(map[string]*Alias, len(snapshot.Aliases))
+	for _, alias := range snapshot.Aliases {
+		aliases[alias.Name] = alias.clone()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = entries
+	r.aliases = aliases
+	r.version = snapshot.Version
+	r.index.reset(entries)
+	if r.onSize != nil {
+		r.onSize(len(r.entries))
+	}
+}
+
+// CapabilityQuery finds registered nodes by what they can do. Type, Method,
+// Tags and Health filter entries; Text ranks them by keyword relevance and, when
+// the registry has an embedder, semantic similarity.
+type CapabilityQuery struct {
+	// Text is matched against node and method names, descriptions and tags
+	Text string `json:"text,omitempty"`
+	// Type only matches nodes of this type
+	Type NodeType `json:"type,omitempty"`
+	// Method only matches nodes offering this method
+	Method string `json:"method,omitempty"`
+	// Tags only matches nodes with every tag
+	Tags []string `json:"tags,omitempty"`
+	// Health only matches nodes in this health
+	Health RegistryHealth `json:"health,omitempty"`
+	// Limit caps the number of results (0 returns every match)
+	Limit int `json:"limit,omitempty"`
+	// MinScore drops text matches scoring below it
+	MinScore float64 `json:"minScore,omitempty"`
+}
+
+// matches reports whether an entry passes the query's filters
+func (q *CapabilityQuery) matches(e *RegistryEntry) bool {
+	if q.Type != "" && e.Type != q.Type {
+		return false
+	}
+	if q.Health != "" && e.Health != q.Health {
+		return false
+	}
+	if q.Method != "" {
+		if _, ok := e.Methods[q.Method]; !ok {
+			return false
+		}
+	}
+	for _, tag := range q.Tags {
+		found := false
+		for _, t := range e.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// RegistryMatch is a registry entry ranked by a CapabilityQuery
+type RegistryMatch struct {
+	*RegistryEntry
+	// Score orders the results: the keyword score plus the semantic score.
+	// Every match scores 1 when the query has no text.
+	Score float64 `json:"score"`
+	// KeywordScore is the text's keyword relevance
+	KeywordScore float64 `json:"keywordScore"`
+	// SemanticScore is the cosine similarity of the text and the entry's embedding
+	SemanticScore float64 `json:"semanticScore"`
+}
+
+// Query returns the entries matching query, best first
+func (r *Registry) Query(ctx context.Context, query *CapabilityQuery) ([]*RegistryMatch, error) {
+	if query == nil {
+		query = &CapabilityQuery{}
+	}
+
+	var keyword, semantic map[string]float64
+	if query.Text != "" {
+		keyword = r.index.keywordScores(tokenize(query.Text))
+		var err error
+		if semantic, err = r.index.semanticScores(ctx, query.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	result := make([]*RegistryMatch, 0)
+	for peerID, entry := range r.entries {
+		if !query.matches(entry) {
+			continue
+		}
+		match := &RegistryMatch{RegistryEntry: entry.clone(), Score: 1}
+		if query.Text != "" {
+			match.KeywordScore = keyword[peerID]
+			match.SemanticScore = math.Max(semantic[peerID], 0)
+			match.Score = match.KeywordScore + match.SemanticScore
+			if match.Score <= 0 || match.Score < query.MinScore {
+				continue
+			}
+		}
+		result = append(result, match)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Address < result[j].Address
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// AuthorizePeer implements config.PeerAuthorizer, allowing only registered peers.
+// It can restrict GossipSub senders to the nodes a leader knows. It cannot be the
+// connection gater's authorizer on a leader: nodes connect to the leader before
+// they register, and a gater sees connections, not protocols, so it would refuse
+// every registration.
+func (r *Registry) AuthorizePeer(p peer.ID) (bool, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.entries[p.String()]; ok {
+		return true, ""
+	}
+	return false, "peer is not registered"
+}
+
+// Registry returns the leader registry, or nil on nodes that are not leaders
+func (n *CoreNode) Registry() *Registry {
+	return n.registry
+}
+
+// SearchCapabilities queries the leader registry for nodes by capability
+func (n *CoreNode) SearchCapabilities(ctx context.Context, query *CapabilityQuery) ([]*RegistryMatch, error) {
+	if query == nil {
+		query = &CapabilityQuery{}
+	}
+	data, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	var result struct {
+		Data []*RegistryMatch `json:"data"`
+	}
+	if err := n.callRegistry(ctx, "query", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// isRegistryAddress reports whether a target address names the leader registry
+func isRegistryAddress(target string) bool {
+	return target == RegistryAddress || target == RegistryStaticAddress
+}
+
+// handleRegistryRequest serves the registry methods on a leader. Writes are only
+// accepted by the elected leader; followers serve reads from their replica.
+// Nodes may only commit, remove and heartbeat their own entry.
+func (n *CoreNode) handleRegistryRequest(ctx context.Context, req *ORequest) *OResponse {
+	switch req.Method {
+	case "commit", "remove", "heartbeat", "register_alias", "delete_alias":
+		if err := n.requireLeadership(); err != nil {
+			return errorResponse(req.ID, err)
+		}
+	}
+	switch req.Method {
+	case "commit", "remove", "heartbeat":
+		if err := n.authorizeEntryWrite(ctx, stringParam(req.Params, "peerId")); err != nil {
+			return NewOErrorResponse(req.ID, err.Code, err.Message, err.Data)
+		}
+	}
+
+	switch req.Method {
+	case "commit":
+		entry := &RegistryEntry{
+			PeerID:        stringParam(req.Params, "peerId"),
+			Address:       stringParam(req.Params, "address"),
+			StaticAddress: stringParam(req.Params, "staticAddress"),
+			Protocols:     stringsParam(req.Params, "protocols"),
+			Transports:    stringsParam(req.Params, "transports"),
+			Type:          NodeType(stringParam(req.Params, "type")),
+			Description:   stringParam(req.Params, "description"),
+			Methods:       methodsParam(req.Params, "methods"),
+			Tags:          stringsParam(req.Params, "tags"),
+		}
+		if err := n.registry.Commit(entry); err != nil {
+			return NewOErrorResponse(req.ID, ErrorCodeRegistrationFailed, err.Error(), nil)
+		}
+		n.logger.Debugf("Registered %s (%s)", entry.Address, entry.PeerID)
+		return NewOResponse(req.ID, map[string]interface{}{"success": true})
+
+	case "remove":
+		removed := n.registry.Remove(stringParam(req.Params, "peerId"))
+		return NewOResponse(req.ID, map[string]interface{}{"success": removed})
+
+	case "heartbeat":
+		peerID := stringParam(req.Params, "peerId")
+		if !n.registry.Heartbeat(peerID) {
+			err := ErrNotRegistered(peerID)
+			return NewOErrorResponse(req.ID, err.Code, err.Message, err.Data)
+		}
+		return NewOResponse(req.ID, map[string]interface{}{"success": true})
+
+	case "register_alias", "delete_alias", "list_aliases":
+		return n.handleAliasRequest(req)
+
+	case "search":
+		// Aliases take precedence over nodes registered with the same static address
+		if static := stringParam(req.Params, "staticAddress"); static != "" {
+			if target, ok := n.registry.ResolveAlias(static, stringParam(req.Params, "version")); ok {
+				return NewOResponse(req.ID, map[string]interface{}{"data": []map[string]interface{}{{
+					"address": target.Address,
+					"alias":   static,
+					"version": target.Version,
+				}}})
+			}
+		}
+		entries := n.registry.Search(&RegistryQuery{
+			StaticAddress: stringParam(req.Params, "staticAddress"),
+			Address:       stringParam(req.Params, "address"),
+			Protocol:      stringParam(req.Params, "protocol"),
+			Health:        RegistryHealth(stringParam(req.Params, "health")),
+		})
+		return NewOResponse(req.ID, map[string]interface{}{"data": entries})
+
+	case "query":
+		query := &CapabilityQuery{
+			Text:   stringParam(req.Params, "text"),
+			Type:   NodeType(stringParam(req.Params, "type")),
+			Method: stringParam(req.Params, "method"),
+			Tags:   stringsParam(req.Params, "tags"),
+			Health: RegistryHealth(stringParam(req.Params, "health")),
+		}
+		if limit, ok := req.Params["limit"].(float64); ok {
+			query.Limit = int(limit)
+		}
+		if minScore, ok := req.Params["minScore"].(float64); ok {
+			query.MinScore = minScore
+		}
+		matches, err := n.registry.Query(ctx, query)
+		if err != nil {
+			return NewOErrorResponse(req.ID, ErrorCodeGeneral, err.Error(), nil)
+		}
+		return NewOResponse(req.ID, map[string]interface{}{"data": matches})
+
+	case "find_all":
+		return NewOResponse(req.ID, map[string]interface{}{"data": n.registry.FindAll()})
+
+	default:
+		err := ErrMethodNotFound(req.Method)
+		return NewOErrorResponse(req.ID, err.Code, err.Message, err.Data)
+	}
+}
+
+// authorizeEntryWrite checks that a request writing the entry of peerID was sent by that peer
+func (n *CoreNode) authorizeEntryWrite(ctx context.Context, peerID string) *OError {
+	remote := n.remotePeer(ctx)
+	if peerID != remote.String() {
+		return ErrUnauthorized(fmt.Sprintf("peer %s cannot write the entry of %s", remote, peerID))
+	}
+	return nil
+}
+
+// stringParam returns a string request parameter, or "" if it is missing
+func stringParam(params map[string]interface{}, name string) string {
+	value, _ := params[name].(string)
+	return value
+}
+
+// methodsParam decodes a method description map request parameter, or returns nil
+func methodsParam(params map[string]interface{}, name string) map[string]*OMethod {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var methods map[string]*OMethod
+	if err := json.Unmarshal(data, &methods); err != nil {
+		return nil
+	}
+	return methods
+}
+
+// stringsParam returns a string list request parameter, skipping non-string items
+func stringsParam(params map[string]interface{}, name string) []string {
+	switch values := params[name].(type) {
+	case []string:
+		return append([]string(nil), values...)
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return []string{}
+}
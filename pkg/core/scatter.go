@@ -0,0 +1,290 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/olane-labs/olane-go/pkg/node"
+)
+
+// ScatterReplyProtocol carries responses to scattered requests back to the requester.
+// It lives outside /o/ so leaders, which accept every o-protocol, do not claim it.
+const ScatterReplyProtocol = protocol.ID("/olane/scatter-reply/1.0.0")
+
+// Reserved parameters of scattered requests
+const (
+	// ParamReplyTransports carries the multiaddrs responders dial to reply
+	ParamReplyTransports = "_replyTransports"
+	// ParamDeadline carries the request deadline in Unix milliseconds
+	ParamDeadline = "_deadline"
+)
+
+// DefaultScatterTimeout bounds a scatter-gather when neither the context nor the options set a deadline
+const DefaultScatterTimeout = 5 * time.Second
+
+// querySegment prefixes the topics scattered requests are published on
+const querySegment = "/o/queries/"
+
+// QueryTopic returns the topic for requests to every node offering a capability,
+// e.g. "calculate" gives /o/queries/calculate
+func QueryTopic(capability string) string {
+	return querySegment + capability
+}
+
+// ScatterOptions controls how long ScatterGather collects responses
+type ScatterOptions struct {
+	// MaxResponses returns as soon as this many nodes have responded (0 waits for the deadline)
+	MaxResponses int
+	// Timeout bounds the collection (defaults to DefaultScatterTimeout; an earlier context deadline wins)
+	Timeout time.Duration
+}
+
+// ScatterResponse is one node's response to a scattered request
+type ScatterResponse struct {
+	// Address is the o-address of the responding node
+	Address *OAddress
+	// PeerID is the peer the response arrived from
+	PeerID peer.ID
+	// Response is the node's response to the request
+	Response *OResponse
+}
+
+// scatterReply is the wire format of a response on ScatterReplyProtocol
+type scatterReply struct {
+	Address  string     `json:"address"`
+	Response *OResponse `json:"response"`
+}
+
+// pendingScatter collects the replies to a request until its gather returns
+type pendingScatter struct {
+	responses chan *ScatterResponse
+	done      chan struct{}
+}
+
+// ScatterSubscription is an active subscription created by ServeScatter
+type ScatterSubscription struct {
+	sub *node.TopicSubscription
+}
+
+// Topic returns the GossipSub topic of the subscription
+func (s *ScatterSubscription) Topic() string {
+	return s.sub.Topic()
+}
+
+// Cancel stops answering requests on the topic and waits for the subscription to close.
+// Requests already being handled still reply.
+func (s *ScatterSubscription) Cancel() {
+	s.sub.Cancel()
+}
+
+// ScatterGather publishes a request for method to topic and collects the responses
+// of the nodes serving it with ServeScatter. It returns when MaxResponses nodes have
+// responded or the deadline passes, with whatever responses arrived, at most one
+// per node. Responses are in arrival order and may include errors returned by
+// handlers. An error is returned only if the request cannot be published or ctx
+// is cancelled before the deadline.
+func (n *CoreNode) ScatterGather(ctx context.Context, topic, method string, params map[string]interface{}, opts *ScatterOptions) ([]*ScatterResponse, error) {
+	if opts == nil {
+		opts = &ScatterOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultScatterTimeout
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := n.tracer.Start(ctx, "olane.ScatterGather",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrMethod.String(method), attribute.String("olane.topic", topic)))
+	defer span.End()
+
+	deadline, _ := ctx.Deadline()
+	reqParams := make(map[string]interface{}, len(params)+4)
+	for k, v := range params {
+		reqParams[k] = v
+	}
+	reqParams[ParamCallerAddress] = n.address.String()
+	reqParams[ParamReplyTransports] = n.Transports()
+	reqParams[ParamDeadline] = deadline.UnixMilli()
+	reqParams[ParamProtocolVersion] = ProtocolVersion
+	injectTraceContext(ctx, reqParams)
+
+	req := NewORequest(newRequestID(), method, reqParams)
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	pending := &pendingScatter{
+		responses: make(chan *ScatterResponse, 16),
+		done:      make(chan struct{}),
+	}
+	n.mu.Lock()
+	n.scatters[req.ID] = pending
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.scatters, req.ID)
+		n.mu.Unlock()
+		close(pending.done)
+	}()
+
+	topics, err := n.topicCache()
+	if err != nil {
+		return nil, err
+	}
+	if err := topics.Publish(ctx, topic, data); err != nil {
+		return nil, fmt.Errorf("failed to publish request to %s: %w", topic, err)
+	}
+	n.logger.Debugf("Scattered %s to %s", method, topic)
+
+	var results []*ScatterResponse
+	seen := make(map[peer.ID]struct{})
+	for {
+		select {
+		case r := <-pending.responses:
+			if _, ok := seen[r.PeerID]; ok {
+				continue
+			}
+			seen[r.PeerID] = struct{}{}
+			results = append(results, r)
+			if opts.MaxResponses > 0 && len(results) >= opts.MaxResponses {
+				span.SetAttributes(attribute.Int("olane.responses", len(results)))
+				return results, nil
+			}
+		case <-ctx.Done():
+			span.SetAttributes(attribute.Int("olane.responses", len(results)))
+			if err := parent.Err(); err != nil {
+				return results, err
+			}
+			return results, nil
+		}
+	}
+}
+
+// handleScatterReply delivers a response on ScatterReplyProtocol to its pending gather
+func (n *CoreNode) handleScatterReply(s network.Stream) {
+	defer s.Close()
+
+	var reply scatterReply
+	if err := json.NewDecoder(s).Decode(&reply); err != nil || reply.Response == nil {
+		n.logger.Debugf("Failed to read scatter reply from %s: %v", s.Conn().RemotePeer(), err)
+		s.Reset()
+		return
+	}
+
+	n.mu.RLock()
+	pending, ok := n.scatters[reply.Response.ID]
+	n.mu.RUnlock()
+	if !ok {
+		n.logger.Debugf("Dropped late scatter reply from %s", reply.Address)
+		return
+	}
+
+	select {
+	case pending.responses <- &ScatterResponse{
+		Address:  NewOAddress(reply.Address),
+		PeerID:   s.Conn().RemotePeer(),
+		Response: reply.Response,
+	}:
+	case <-pending.done:
+	}
+}
+
+// ServeScatter answers requests scattered to topic by other nodes. Each request
+// is handled like a direct request to this node and the response is sent back
+// to the requester over a new stream. Requests for methods this node does not
+// handle and requests past their deadline are ignored.
+func (n *CoreNode) ServeScatter(topic string) (*ScatterSubscription, error) {
+	topics, err := n.topicCache()
+	if err != nil {
+		return nil, err
+	}
+	sub, err := topics.SubscribeFunc(topic, func(ctx context.Context, msg *pubsub.Message) {
+		if msg.GetFrom() == n.ID() {
+			return
+		}
+
+		var req ORequest
+		if err := json.Unmarshal(msg.Data, &req); err != nil || req.ID == "" {
+			n.logger.Warnf("Dropped invalid request on %s from %s", topic, msg.GetFrom())
+			return
+		}
+		go n.answerScatter(n.context(), msg.GetFrom(), &req)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ScatterSubscription{sub: sub}, nil
+}
+
+// answerScatter handles a scattered request and replies to the requester
+func (n *CoreNode) answerScatter(ctx context.Context, from peer.ID, req *ORequest) {
+	if req.Params == nil {
+		req.Params = make(map[string]interface{})
+	}
+	if deadline, ok := req.Params[ParamDeadline].(float64); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, time.UnixMilli(int64(deadline)))
+		defer cancel()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	h := n.Host()
+	if h == nil {
+		return
+	}
+	if transports, ok := req.Params[ParamReplyTransports].([]interface{}); ok {
+		var addrs []multiaddr.Multiaddr
+		for _, t := range transports {
+			value, _ := t.(string)
+			if ma, err := multiaddr.NewMultiaddr(value); err == nil {
+				addrs = append(addrs, ma)
+			}
+		}
+		h.Peerstore().AddAddrs(from, addrs, peerstore.TempAddrTTL)
+	}
+
+	// Scattered requests pass the same hello and version gate as direct ones
+	if err := n.admitPeer(ctx, h, from); err != nil {
+		n.logger.Debugf("Refused scattered %s request from %s: %v", req.Method, from, err)
+		return
+	}
+
+	req.Params[ParamTargetAddress] = n.address.String()
+	response := n.dispatchRequest(withRemotePeer(ctx, from), req)
+	if response.Error != nil && response.Error.Code == ErrorCodeMethodNotFound {
+		return
+	}
+
+	s, err := h.NewStream(ctx, from, ScatterReplyProtocol)
+	if err != nil {
+		n.logger.Debugf("Failed to reply to scattered request from %s: %v", from, err)
+		return
+	}
+	defer s.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetDeadline(deadline)
+	}
+	if err := json.NewEncoder(s).Encode(&scatterReply{Address: n.address.String(), Response: response}); err != nil {
+		n.logger.Debugf("Failed to write scatter reply to %s: %v", from, err)
+		s.Reset()
+	}
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestQueryTopic(t *testing.T) {
+	if topic := QueryTopic("calculate"); topic != "/o/queries/calculate" {
+		t.Errorf("Expected /o/queries/calculate, got %s", topic)
+	}
+}
+
+func TestScatterGather(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	requester := newLoopbackNode("o://agents/planner", NodeTypeNode, nil)
+	if err := requester.Start(ctx); err != nil {
+		t.Fatalf("Failed to start requester: %v", err)
+	}
+	defer requester.Stop(ctx)
+
+	topic := QueryTopic("calculate")
+	for _, address := range []string{"o://tools/calc-a", "o://tools/calc-b", "o://tools/clock"} {
+		responder := newLoopbackNode(address, NodeTypeTool, nil)
+		if address != "o://tools/clock" {
+			responder.Handle("add", func(ctx context.Context, req *ORequest) (interface{}, error) {
+				return req.Params["a"].(float64) + req.Params["b"].(float64), nil
+			})
+		}
+		if err := responder.Start(ctx); err != nil {
+			t.Fatalf("Failed to start %s: %v", address, err)
+		}
+		defer responder.Stop(ctx)
+
+		if err := responder.Host().Connect(ctx, peer.AddrInfo{ID: requester.ID(), Addrs: requester.Host().Addrs()}); err != nil {
+			t.Fatalf("Failed to connect %s: %v", address, err)
+		}
+		sub, err := responder.ServeScatter(topic)
+		if err != nil {
+			t.Fatalf("Failed to serve %s: %v", topic, err)
+		}
+		defer sub.Cancel()
+	}
+
+	// Subscriptions propagate asynchronously, so scatter until both calculators answer
+	params := map[string]interface{}{"a": 1, "b": 2}
+	var results []*ScatterResponse
+	for len(results) < 2 {
+		var err error
+		results, err = requester.ScatterGather(ctx, topic, "add", params, &ScatterOptions{Timeout: time.Second})
+		if err != nil {
+			t.Fatalf("Failed to scatter: %v", err)
+		}
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 responses, got %d", len(results))
+	}
+	var addresses []string
+	for _, r := range results {
+		addresses = append(addresses, r.Address.String())
+		if r.Response.Error != nil || r.Response.Result != float64(3) {
+			t.Errorf("Expected result 3 from %s, got %v (%v)", r.Address, r.Response.Result, r.Response.Error)
+		}
+	}
+	sort.Strings(addresses)
+	if addresses[0] != "o://tools/calc-a" || addresses[1] != "o://tools/calc-b" {
+		t.Errorf("Expected responses from both calculators, got %v", addresses)
+	}
+
+	start := time.Now()
+	results, err := requester.ScatterGather(ctx, topic, "add", params, &ScatterOptions{MaxResponses: 1, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to scatter: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 response, got %d", len(results))
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expected gather to return after the first response")
+	}
+}
+
+func TestScatterGatherCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	requester := newLoopbackNode("o://agents/planner", NodeTypeNode, nil)
+	if err := requester.Start(ctx); err != nil {
+		t.Fatalf("Failed to start requester: %v", err)
+	}
+	defer requester.Stop(ctx)
+
+	scatterCtx, scatterCancel := context.WithCancel(ctx)
+	scatterCancel()
+	if _, err := requester.ScatterGather(scatterCtx, QueryTopic("calculate"), "add", nil, nil); err == nil {
+		t.Error("Expected error for a cancelled context")
+	}
+
+	results, err := requester.ScatterGather(ctx, QueryTopic("calculate"), "add", nil, &ScatterOptions{Timeout: 100 * time.Millisecond})
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected no responses and no error, got %v (%v)", results, err)
+	}
+}
+
+func TestScatterRequiresHello(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	requester := newLoopbackNode("o://agents/planner", NodeTypeNode, nil)
+	requester.config.NetworkName = "elsewhere"
+	if err := requester.Start(ctx); err != nil {
+		t.Fatalf("Failed to start requester: %v", err)
+	}
+	defer requester.Stop(ctx)
+
+	// The open calculator accepts any network, the scoped one only its own
+	topic := QueryTopic("calculate")
+	for address, network := range map[string]string{"o://tools/open": "", "o://tools/scoped": "olane"} {
+		responder := newLoopbackNode(address, NodeTypeTool, nil)
+		responder.config.NetworkName = network
+		responder.Handle("add", func(ctx context.Context, req *ORequest) (interface{}, error) {
+			return 3, nil
+		})
+		if err := responder.Start(ctx); err != nil {
+			t.Fatalf("Failed to start %s: %v", address, err)
+		}
+		defer responder.Stop(ctx)
+
+		if err := responder.Host().Connect(ctx, peer.AddrInfo{ID: requester.ID(), Addrs: requester.Host().Addrs()}); err != nil {
+			t.Fatalf("Failed to connect %s: %v", address, err)
+		}
+		sub, err := responder.ServeScatter(topic)
+		if err != nil {
+			t.Fatalf("Failed to serve %s: %v", topic, err)
+		}
+		defer sub.Cancel()
+	}
+
+	waitUntil(t, ctx, "both calculators to subscribe", func() bool {
+		return len(requester.pubsub.ListPeers(topic)) == 2
+	})
+	results, err := requester.ScatterGather(ctx, topic, "add", nil, &ScatterOptions{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Failed to scatter: %v", err)
+	}
+	if len(results) != 1 || results[0].Address.String() != "o://tools/open" {
+		t.Errorf("Expected only the open calculator to answer, got %v", results)
+	}
+}
+
+func TestAnswerScatterAfterStop(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	responder := newLoopbackNode("o://tools/calc", NodeTypeTool, nil)
+	if err := responder.Start(ctx); err != nil {
+		t.Fatalf("Failed to start responder: %v", err)
+	}
+	from := responder.ID()
+	if err := responder.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop responder: %v", err)
+	}
+
+	// A reply still in flight when the node stops is dropped instead of panicking
+	responder.answerScatter(ctx, from, NewORequest("1", "add", nil))
+}
@@ -0,0 +1,417 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Embedder turns text into a vector for semantic registry search
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// VectorMatch is a vector index result
+type VectorMatch struct {
+	ID    string
+	Score float64
+}
+
+// VectorIndex stores embeddings and finds the ones nearest to a query vector.
+// Implementations must be safe for concurrent use.
+type VectorIndex interface {
+	Upsert(id string, vector []float32) error
+	Delete(id string) error
+	// Search returns up to limit matches ordered by descending similarity (limit <= 0 returns all)
+	Search(vector []float32, limit int) ([]VectorMatch, error)
+}
+
+// Field weights of the keyword index; names are stronger signals than prose
+const (
+	weightMethod      = 3.0
+	weightTag         = 3.0
+	weightType        = 2.0
+	weightAddress     = 2.0
+	weightDescription = 1.0
+)
+
+// stopWords are left out of the keyword index and queries
+var stopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "by": {}, "for": {}, "from": {}, "in": {},
+	"is": {}, "it": {}, "of": {}, "on": {}, "or": {}, "the": {}, "to": {}, "with": {},
+}
+
+// tokenize lowercases text and splits it into words, breaking identifiers such as
+// getWeather and add_numbers into their parts
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		token := string(word)
+		word = word[:0]
+		if _, ok := stopWords[token]; !ok {
+			tokens = append(tokens, token)
+		}
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && unicode.IsLower(runes[i-1]) {
+				flush()
+			}
+			word = append(word, unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// document is the indexed form of a registry entry
+type document struct {
+	terms    map[string]float64
+	text     string
+	embedded bool
+}
+
+// newDocument builds the weighted terms and embedding text of an entry
+func newDocument(entry *RegistryEntry) *document {
+	doc := &document{terms: make(map[string]float64)}
+	var text []string
+	add := func(value string, weight float64) {
+		if value == "" {
+			return
+		}
+		text = append(text, value)
+		for _, token := range tokenize(value) {
+			doc.terms[token] += weight
+		}
+	}
+
+	add(string(entry.Type), weightType)
+	add(strings.TrimPrefix(entry.Address, "o://"), weightAddress)
+	add(strings.TrimPrefix(entry.StaticAddress, "o://"), weightAddress)
+	add(entry.Description, weightDescription)
+	for _, tag := range entry.Tags {
+		add(tag, weightTag)
+	}
+	names := make([]string, 0, len(entry.Methods))
+	for name := range entry.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, weightMethod)
+		if method := entry.Methods[name]; method != nil {
+			add(method.Description, weightDescription)
+		}
+	}
+
+	doc.text = strings.Join(text, "\n")
+	return doc
+}
+
+// searchIndex is the registry's keyword index, with optional embeddings for
+// semantic search. Documents are embedded lazily when a semantic query runs,
+// so committing entries never waits on the embedder.
+type searchIndex struct {
+	docs     map[string]*document
+	postings map[string]map[string]float64
+	embedder Embedder
+	vectors  VectorIndex
+	mu       sync.Mutex
+}
+
+// newSearchIndex creates an empty keyword index
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+// put indexes an entry, replacing any previous version of it
+func (x *searchIndex) put(entry *RegistryEntry) {
+	doc := newDocument(entry)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if old, ok := x.docs[entry.PeerID]; ok {
+		if old.text == doc.text {
+			return
+		}
+		x.unpost(entry.PeerID, old)
+	}
+	x.docs[entry.PeerID] = doc
+	for term, weight := range doc.terms {
+		if x.postings[term] == nil {
+			x.postings[term] = make(map[string]float64)
+		}
+		x.postings[term][entry.PeerID] = weight
+	}
+}
+
+// remove drops an entry from the index
+func (x *searchIndex) remove(peerID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if doc, ok := x.docs[peerID]; ok {
+		x.unpost(peerID, doc)
+		delete(x.docs, peerID)
+		if x.vectors != nil {
+			_ = x.vectors.Delete(peerID)
+		}
+	}
+}
+
+// unpost removes a document's postings; callers hold the lock
+func (x *searchIndex) unpost(peerID string, doc *document) {
+	for term := range doc.terms {
+		delete(x.postings[term], peerID)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+}
+
+// reset re-indexes the index to hold exactly entries, keeping unchanged documents
+func (x *searchIndex) reset(entries map[string]*RegistryEntry) {
+	x.mu.Lock()
+	var stale []string
+	for peerID := range x.docs {
+		if _, ok := entries[peerID]; !ok {
+			stale = append(stale, peerID)
+		}
+	}
+	x.mu.Unlock()
+
+	for _, peerID := range stale {
+		x.remove(peerID)
+	}
+	for _, entry := range entries {
+		x.put(entry)
+	}
+}
+
+// setEmbedder enables semantic search, embedding every document on the next query
+func (x *searchIndex) setEmbedder(embedder Embedder, vectors VectorIndex) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.embedder = embedder
+	x.vectors = vectors
+	for _, doc := range x.docs {
+		doc.embedded = false
+	}
+}
+
+// keywordScores ranks documents against query terms with BM25-style inverse
+// document frequency, averaged over the terms
+func (x *searchIndex) keywordScores(terms []string) map[string]float64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	scores := make(map[string]float64)
+	if len(terms) == 0 {
+		return scores
+	}
+	total := float64(len(x.docs))
+	for _, term := range terms {
+		posting := x.postings[term]
+		df := float64(len(posting))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+		for peerID, weight := range posting {
+			scores[peerID] += weight * idf / float64(len(terms))
+		}
+	}
+	return scores
+}
+
+// semanticScores embeds any changed documents and the query text, returning the
+// cosine similarity of every document to the query. It returns nil without an embedder.
+// The embedder runs without the lock so commits are not held up by it.
+func (x *searchIndex) semanticScores(ctx context.Context, text string) (map[string]float64, error) {
+	x.mu.Lock()
+	embedder, vectors := x.embedder, x.vectors
+	pending := make(map[string]*document)
+	for peerID, doc := range x.docs {
+		if !doc.embedded {
+			pending[peerID] = doc
+		}
+	}
+	x.mu.Unlock()
+	if embedder == nil {
+		return nil, nil
+	}
+
+	for peerID, doc := range pending {
+		vector, err := embedder.Embed(ctx, doc.text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %s: %w", peerID, err)
+		}
+		if err := x.upsert(peerID, doc, vectors, vector); err != nil {
+			return nil, err
+		}
+	}
+
+	vector, err := embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	matches, err := vectors.Search(vector, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search embeddings: %w", err)
+	}
+	scores := make(map[string]float64, len(matches))
+	for _, m := range matches {
+		scores[m.ID] = m.Score
+	}
+	return scores, nil
+}
+
+// upsert stores the embedding of a document unless it was replaced, removed or
+// the embedder changed while it was being embedded
+func (x *searchIndex) upsert(peerID string, doc *document, vectors VectorIndex, vector []float32) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.docs[peerID] != doc || x.vectors != vectors {
+		return nil
+	}
+	if err := vectors.Upsert(peerID, vector); err != nil {
+		return fmt.Errorf("failed to index embedding of %s: %w", peerID, err)
+	}
+	doc.embedded = true
+	return nil
+}
+
+// HashingEmbedder embeds text locally by hashing its words into a fixed number
+// of dimensions. It needs no model, so texts sharing words are similar but
+// synonyms are not; it suits tests and small networks.
+type HashingEmbedder struct {
+	Dimensions int
+}
+
+// DefaultEmbeddingDimensions is the HashingEmbedder size used when Dimensions is unset
+const DefaultEmbeddingDimensions = 256
+
+// NewHashingEmbedder creates a hashing embedder with the given number of dimensions
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	return &HashingEmbedder{Dimensions: dimensions}
+}
+
+// Embed implements Embedder, returning an L2-normalized vector
+func (h *HashingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	dimensions := h.Dimensions
+	if dimensions <= 0 {
+		dimensions = DefaultEmbeddingDimensions
+	}
+
+	vector := make([]float32, dimensions)
+	for _, token := range tokenize(text) {
+		hash := fnv.New64a()
+		hash.Write([]byte(token))
+		sum := hash.Sum64()
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(dimensions)] += sign
+	}
+	normalize(vector)
+	return vector, nil
+}
+
+// normalize scales a vector to unit length in place
+func normalize(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+}
+
+// cosine returns the cosine similarity of two vectors, or 0 if their sizes differ
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// MemoryVectorIndex is an in-memory VectorIndex that compares the query with every vector
+type MemoryVectorIndex struct {
+	vectors map[string][]float32
+	mu      sync.RWMutex
+}
+
+// NewMemoryVectorIndex creates an empty in-memory vector index
+func NewMemoryVectorIndex() *MemoryVectorIndex {
+	return &MemoryVectorIndex{vectors: make(map[string][]float32)}
+}
+
+// Upsert implements VectorIndex
+func (m *MemoryVectorIndex) Upsert(id string, vector []float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vectors[id] = append([]float32(nil), vector...)
+	return nil
+}
+
+// Delete implements VectorIndex
+func (m *MemoryVectorIndex) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vectors, id)
+	return nil
+}
+
+// Search implements VectorIndex by cosine similarity
+func (m *MemoryVectorIndex) Search(vector []float32, limit int) ([]VectorMatch, error) {
+	m.mu.RLock()
+	matches := make([]VectorMatch, 0, len(m.vectors))
+	for id, v := range m.vectors {
+		matches = append(matches, VectorMatch{ID: id, Score: cosine(vector, v)})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
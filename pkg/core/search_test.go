@@ -0,0 +1,257 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/olane-labs/olane-go/pkg/utils"
+)
+
+// testPeerID derives a stable peer ID from a seed
+func testPeerID(t *testing.T, seed string) string {
+	key, err := utils.DeriveEd25519Key(seed)
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to derive peer ID: %v", err)
+	}
+	return id.String()
+}
+
+// newCapabilityRegistry returns a registry with a weather tool, a calculator and a planning agent
+func newCapabilityRegistry(t *testing.T) *Registry {
+	registry := NewRegistry()
+	entries := []*RegistryEntry{
+		{
+			PeerID:  testPeerID(t, "weather"),
+			Address: "o://leader/tools/weather",
+			Type:    NodeTypeTool,
+			Methods: map[string]*OMethod{
+				"getForecast": {Name: "getForecast", Description: "Returns the weather forecast for a city"},
+			},
+			Tags: []string{"weather", "external"},
+		},
+		{
+			PeerID:      testPeerID(t, "calc"),
+			Address:     "o://leader/tools/calc",
+			Type:        NodeTypeTool,
+			Description: "Arithmetic on numbers",
+			Methods:     map[string]*OMethod{"add": {Name: "add", Description: "Adds two numbers"}},
+			Tags:        []string{"math"},
+		},
+		{
+			PeerID:      testPeerID(t, "planner"),
+			Address:     "o://leader/agents/planner",
+			Type:        NodeTypeAgent,
+			Description: "Plans trips using the weather service",
+		},
+	}
+	for _, entry := range entries {
+		if err := registry.Commit(entry); err != nil {
+			t.Fatalf("Failed to commit %s: %v", entry.Address, err)
+		}
+	}
+	return registry
+}
+
+// addresses returns the addresses of matches in order
+func addresses(matches []*RegistryMatch) []string {
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.Address
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := tokenize("getWeather add_numbers for the HTTP2 API")
+	expected := []string{"get", "weather", "add", "numbers", "http2", "api"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+}
+
+func TestRegistryQuery(t *testing.T) {
+	ctx := context.Background()
+	registry := newCapabilityRegistry(t)
+
+	tests := []struct {
+		name     string
+		query    *CapabilityQuery
+		expected []string
+	}{
+		{"text ranks names above prose", &CapabilityQuery{Text: "weather forecast"}, []string{"o://leader/tools/weather", "o://leader/agents/planner"}},
+		{"method", &CapabilityQuery{Method: "add"}, []string{"o://leader/tools/calc"}},
+		{"type and tags", &CapabilityQuery{Type: NodeTypeTool, Tags: []string{"weather", "external"}}, []string{"o://leader/tools/weather"}},
+		{"type", &CapabilityQuery{Type: NodeTypeAgent}, []string{"o://leader/agents/planner"}},
+		{"limit", &CapabilityQuery{Text: "weather", Limit: 1}, []string{"o://leader/tools/weather"}},
+		{"no match", &CapabilityQuery{Text: "translate"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := registry.Query(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if got := addresses(matches); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Removed entries leave the index
+	registry.Remove(testPeerID(t, "weather"))
+	matches, _ := registry.Query(ctx, &CapabilityQuery{Text: "forecast"})
+	if len(matches) != 0 {
+		t.Errorf("Expected no forecast matches after removal, got %v", addresses(matches))
+	}
+
+	// Replicas index restored entries
+	replica := NewRegistry()
+	replica.Restore(newCapabilityRegistry(t).Snapshot())
+	matches, _ = replica.Query(ctx, &CapabilityQuery{Text: "forecast"})
+	if got := addresses(matches); len(got) != 1 || got[0] != "o://leader/tools/weather" {
+		t.Errorf("Expected the weather tool from the replica, got %v", got)
+	}
+}
+
+func TestRegistrySemanticQuery(t *testing.T) {
+	ctx := context.Background()
+	registry := newCapabilityRegistry(t)
+	registry.SetEmbedder(NewHashingEmbedder(512), nil)
+
+	matches, err := registry.Query(ctx, &CapabilityQuery{Text: "city forecast"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(matches) == 0 || matches[0].Address != "o://leader/tools/weather" {
+		t.Fatalf("Expected the weather tool first, got %v", addresses(matches))
+	}
+	if matches[0].SemanticScore <= 0 || matches[0].Score != matches[0].KeywordScore+matches[0].SemanticScore {
+		t.Errorf("Expected a combined keyword and semantic score, got %+v", matches[0])
+	}
+
+	embedder := NewHashingEmbedder(64)
+	a, _ := embedder.Embed(ctx, "weather forecast")
+	b, _ := embedder.Embed(ctx, "forecast weather")
+	if score := cosine(a, b); score < 0.999 {
+		t.Errorf("Expected word order not to matter, got similarity %f", score)
+	}
+}
+
+// blockingEmbedder embeds with a HashingEmbedder once released, recording the texts it saw
+type blockingEmbedder struct {
+	started chan struct{}
+	release chan struct{}
+	texts   chan string
+}
+
+// Embed implements Embedder
+func (b *blockingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.release
+	b.texts <- text
+	return NewHashingEmbedder(64).Embed(ctx, text)
+}
+
+func TestRegistryCommitDuringEmbedding(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	registry := newCapabilityRegistry(t)
+	embedder := &blockingEmbedder{started: make(chan struct{}, 1), release: make(chan struct{}), texts: make(chan string, 100)}
+	registry.SetEmbedder(embedder, nil)
+
+	queried := make(chan error, 1)
+	go func() {
+		_, err := registry.Query(ctx, &CapabilityQuery{Text: "forecast"})
+		queried <- err
+	}()
+	select {
+	case <-embedder.started:
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the query to embed")
+	}
+
+	// Commits go through while the embedder is busy
+	committed := make(chan error, 1)
+	go func() {
+		committed <- registry.Commit(&RegistryEntry{
+			PeerID:      testPeerID(t, "calc"),
+			Address:     "o://leader/tools/calc",
+			Description: "Converts currencies",
+		})
+	}()
+	select {
+	case err := <-committed:
+		if err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected commit not to wait on the embedder")
+	}
+
+	close(embedder.release)
+	if err := <-queried; err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// The entry replaced mid-embedding is embedded again on the next query
+	for len(embedder.texts) > 0 {
+		<-embedder.texts
+	}
+	if _, err := registry.Query(ctx, &CapabilityQuery{Text: "currency"}); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var embedded []string
+	for len(embedder.texts) > 0 {
+		embedded = append(embedded, <-embedder.texts)
+	}
+	if len(embedded) != 2 || !strings.Contains(embedded[0], "Converts currencies") {
+		t.Errorf("Expected the replaced entry and the query to be embedded, got %q", embedded)
+	}
+}
+
+func TestSearchCapabilities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	leader.registry.SetEmbedder(NewHashingEmbedder(256), nil)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	tool := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	tool.config.Tags = []string{"math"}
+	tool.config.Leader = leaderAddress(leader.ID(), leader.Transports())
+	tool.Handle("add", func(ctx context.Context, req *ORequest) (interface{}, error) {
+		return nil, nil
+	})
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	matches, err := tool.SearchCapabilities(ctx, &CapabilityQuery{Method: "add", Tags: []string{"math"}, Text: "calc"})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(matches) != 1 || matches[0].PeerID != tool.ID().String() {
+		t.Fatalf("Expected the tool, got %v", addresses(matches))
+	}
+	if matches[0].Type != NodeTypeTool || matches[0].Score <= 0 || matches[0].Health != RegistryHealthHealthy {
+		t.Errorf("Expected a ranked healthy tool entry, got %+v", matches[0])
+	}
+}
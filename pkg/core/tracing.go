@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ParamTraceContext carries the W3C trace context of a request
+const ParamTraceContext = "_traceContext"
+
+// tracerName identifies the spans created by this package
+const tracerName = "github.com/olane-labs/olane-go/pkg/core"
+
+// Span attribute keys
+const (
+	attrAddress      = attribute.Key("olane.address")
+	attrMethod       = attribute.Key("olane.method")
+	attrNextHop      = attribute.Key("olane.next_hop")
+	attrTarget       = attribute.Key("olane.target")
+	attrCaller       = attribute.Key("olane.caller")
+	attrErrorCode    = attribute.Key("olane.error_code")
+	attrLocalAddress = attribute.Key("olane.local_address")
+	attrRemotePeer   = attribute.Key("olane.remote_peer")
+)
+
+// tracePropagator encodes trace context into request payloads
+var tracePropagator = propagation.TraceContext{}
+
+// newTracer returns the tracer for a node. A nil provider falls back to the
+// global provider, which is a no-op unless the application installs one.
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName, trace.WithInstrumentationVersion(Version))
+}
+
+// injectTraceContext stores the trace context of ctx in the request parameters
+func injectTraceContext(ctx context.Context, params map[string]interface{}) {
+	carrier := propagation.MapCarrier{}
+	tracePropagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return
+	}
+
+	encoded := make(map[string]interface{}, len(carrier))
+	for k, v := range carrier {
+		encoded[k] = v
+	}
+	params[ParamTraceContext] = encoded
+}
+
+// extractTraceContext returns ctx with the remote trace context from the request parameters
+func extractTraceContext(ctx context.Context, params map[string]interface{}) context.Context {
+	carrier := propagation.MapCarrier{}
+	switch tc := params[ParamTraceContext].(type) {
+	case map[string]interface{}:
+		for k, v := range tc {
+			if s, ok := v.(string); ok {
+				carrier[k] = s
+			}
+		}
+	case map[string]string:
+		for k, v := range tc {
+			carrier[k] = v
+		}
+	default:
+		return ctx
+	}
+	return tracePropagator.Extract(ctx, carrier)
+}
+
+// endSpan records the outcome of a call on its span and ends it
+func endSpan(span trace.Span, response *OResponse, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case response != nil && response.Error != nil:
+		span.SetAttributes(attrErrorCode.Int(response.Error.Code))
+		span.SetStatus(codes.Error, response.Error.Message)
+	}
+	span.End()
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/multiformats/go-multiaddr"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+)
+
+// newLoopbackNode creates a node listening on loopback only
+func newLoopbackNode(address string, nodeType NodeType, provider trace.TracerProvider) *CoreNode {
+	return NewCoreNode(loopbackConfig(address, nodeType, provider))
+}
+
+// loopbackConfig returns the configuration of a node listening on loopback only
+func loopbackConfig(address string, nodeType NodeType, provider trace.TracerProvider) *CoreConfig {
+	network := config.DefaultLibp2pConfig()
+	network.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+	network.EnableRelay = false
+	network.EnableDHT = false
+
+	cfg := DefaultCoreConfig()
+	cfg.Address = NewOAddress(address)
+	cfg.Type = nodeType
+	cfg.Network = network
+	cfg.Logger = NewNoOpLogger()
+	cfg.TracerProvider = provider
+	return cfg
+}
+
+// dialAddress returns the node's address with its loopback transports
+func dialAddress(t *testing.T, n *CoreNode) *OAddress {
+	address := n.Address().Clone()
+	ma, err := multiaddr.NewMultiaddr(n.Host().Addrs()[0].String() + "/p2p/" + n.ID().String())
+	if err != nil {
+		t.Fatalf("Failed to build transport: %v", err)
+	}
+	address.SetTransports([]multiaddr.Multiaddr{ma})
+	return address
+}
+
+func TestUsePropagatesTraceContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(ctx)
+
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, provider)
+	if err := leader.Start(ctx); err != nil {
+		t.Fatalf("Failed to start leader: %v", err)
+	}
+	defer leader.Stop(ctx)
+
+	tool := newLoopbackNode("o://tools/echo", NodeTypeTool, provider)
+	tool.Handle("echo", func(ctx context.Context, req *ORequest) (interface{}, error) {
+		return req.Params["value"], nil
+	})
+	if err := tool.Start(ctx); err != nil {
+		t.Fatalf("Failed to start tool: %v", err)
+	}
+	defer tool.Stop(ctx)
+
+	response, err := leader.Use(ctx, dialAddress(t, tool), "echo", map[string]interface{}{"value": "hi"}, nil)
+	if err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	if response.Error != nil {
+		t.Fatalf("Expected success, got error: %v", response.Error)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	for _, name := range []string{"olane.Use", "olane.TranslateAddress", "olane.Connect", "olane.Dispatch"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("Expected span %s, got %d spans", name, len(spans))
+		}
+	}
+
+	use := spans["olane.Use"]
+	dispatch := spans["olane.Dispatch"]
+	if dispatch.SpanContext.TraceID() != use.SpanContext.TraceID() {
+		t.Errorf("Expected dispatch span in trace %s, got %s", use.SpanContext.TraceID(), dispatch.SpanContext.TraceID())
+	}
+	if !dispatch.Parent.IsRemote() || dispatch.Parent.SpanID() != use.SpanContext.SpanID() {
+		t.Errorf("Expected dispatch span to have remote parent %s, got %s", use.SpanContext.SpanID(), dispatch.Parent.SpanID())
+	}
+	if spans["olane.Connect"].Parent.SpanID() != use.SpanContext.SpanID() {
+		t.Error("Expected connect span to be a child of the use span")
+	}
+}
+
+func TestTracingDefaultsToNoop(t *testing.T) {
+	node := newLoopbackNode("o://tools/echo", NodeTypeTool, nil)
+	_, span := node.tracer.Start(context.Background(), "test")
+	defer span.End()
+
+	if span.SpanContext().IsValid() {
+		t.Error("Expected no-op spans without a tracer provider")
+	}
+}
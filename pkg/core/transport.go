@@ -0,0 +1,147 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// TransportKind identifies how a transport reaches an address
+type TransportKind string
+
+const (
+	// TransportLibP2P is a libp2p multiaddr such as /ip4/127.0.0.1/tcp/4001/p2p/12D3...
+	TransportLibP2P TransportKind = "libp2p"
+	// TransportCustom is a URI handled outside libp2p, such as http:// or ws://
+	TransportCustom TransportKind = "custom"
+	// TransportInProcess reaches a node in the same process by name
+	TransportInProcess TransportKind = "inprocess"
+)
+
+// InProcessScheme prefixes in-process transports, as in inproc://calc
+const InProcessScheme = "inproc://"
+
+// Transport is one way to reach an address
+type Transport struct {
+	kind      TransportKind
+	multiaddr multiaddr.Multiaddr
+	uri       string
+}
+
+// NewLibP2PTransport creates a transport from a libp2p multiaddr
+func NewLibP2PTransport(ma multiaddr.Multiaddr) Transport {
+	return Transport{kind: TransportLibP2P, multiaddr: ma}
+}
+
+// NewCustomTransport creates a transport from a custom URI
+func NewCustomTransport(uri string) Transport {
+	return Transport{kind: TransportCustom, uri: uri}
+}
+
+// NewInProcessTransport creates a transport to the in-process node registered under name
+func NewInProcessTransport(name string) Transport {
+	return Transport{kind: TransportInProcess, uri: InProcessScheme + strings.TrimPrefix(name, InProcessScheme)}
+}
+
+// ParseTransport classifies a transport string: inproc:// URIs are in-process,
+// valid multiaddrs are libp2p and anything else is a custom URI
+func ParseTransport(s string) Transport {
+	if strings.HasPrefix(s, InProcessScheme) {
+		return NewInProcessTransport(s)
+	}
+	if strings.HasPrefix(s, "/") {
+		if ma, err := multiaddr.NewMultiaddr(s); err == nil {
+			return NewLibP2PTransport(ma)
+		}
+	}
+	return NewCustomTransport(s)
+}
+
+// toTransport converts a Transport, multiaddr or string to a Transport
+func toTransport(t interface{}) (Transport, bool) {
+	switch v := t.(type) {
+	case Transport:
+		return v, v.kind != ""
+	case *Transport:
+		if v == nil {
+			return Transport{}, false
+		}
+		return *v, v.kind != ""
+	case multiaddr.Multiaddr:
+		if v == nil {
+			return Transport{}, false
+		}
+		return NewLibP2PTransport(v), true
+	case string:
+		if v == "" {
+			return Transport{}, false
+		}
+		return ParseTransport(v), true
+	}
+	return Transport{}, false
+}
+
+// Kind returns how the transport reaches its address
+func (t Transport) Kind() TransportKind {
+	return t.kind
+}
+
+// Multiaddr returns the multiaddr of a libp2p transport, or nil
+func (t Transport) Multiaddr() multiaddr.Multiaddr {
+	return t.multiaddr
+}
+
+// URI returns the URI of a custom or in-process transport, or the string form of a multiaddr
+func (t Transport) URI() string {
+	return t.String()
+}
+
+// String returns the transport as a string
+func (t Transport) String() string {
+	if t.multiaddr != nil {
+		return t.multiaddr.String()
+	}
+	return t.uri
+}
+
+// PeerID returns the peer a libp2p transport dials, from its /p2p component
+func (t Transport) PeerID() (peer.ID, error) {
+	if t.kind != TransportLibP2P {
+		return "", fmt.Errorf("%s transport %s has no peer ID", t.kind, t)
+	}
+	value, err := t.multiaddr.ValueForProtocol(multiaddr.P_P2P)
+	if err != nil {
+		return "", fmt.Errorf("no peer ID in transport %s: %w", t, err)
+	}
+	return peer.Decode(value)
+}
+
+// Equal reports whether two transports have the same kind and address
+func (t Transport) Equal(other Transport) bool {
+	return t.kind == other.kind && t.String() == other.String()
+}
+
+// dedupTransports converts transports to Transports, dropping invalid values
+// and duplicates while keeping the first occurrence of each
+func dedupTransports(transports []interface{}) []Transport {
+	if len(transports) == 0 {
+		return nil
+	}
+	result := make([]Transport, 0, len(transports))
+	seen := make(map[string]bool, len(transports))
+	for _, value := range transports {
+		t, ok := toTransport(value)
+		if !ok {
+			continue
+		}
+		key := string(t.kind) + " " + t.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, t)
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestParseTransport(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  TransportKind
+	}{
+		{"/ip4/127.0.0.1/tcp/4001", TransportLibP2P},
+		{"/dns4/example.com/tcp/443/wss", TransportLibP2P},
+		{"http://localhost:8080", TransportCustom},
+		{"ws://localhost:8080/o", TransportCustom},
+		{"/not/a/multiaddr", TransportCustom},
+		{"inproc://calc", TransportInProcess},
+	}
+	for _, tt := range tests {
+		transport := ParseTransport(tt.input)
+		if transport.Kind() != tt.kind {
+			t.Errorf("Expected %s to be %s, got %s", tt.input, tt.kind, transport.Kind())
+		}
+		if transport.String() != tt.input {
+			t.Errorf("Expected %s to round-trip, got %s", tt.input, transport)
+		}
+	}
+	if NewInProcessTransport("calc").String() != "inproc://calc" {
+		t.Errorf("Expected inproc://calc, got %s", NewInProcessTransport("calc"))
+	}
+}
+
+func TestTransportPeerID(t *testing.T) {
+	id, err := peer.Decode(testPeerID(t, "parent"))
+	if err != nil {
+		t.Fatalf("Failed to decode peer ID: %v", err)
+	}
+	withPeer := ParseTransport("/ip4/127.0.0.1/tcp/4001/p2p/" + id.String())
+	if got, err := withPeer.PeerID(); err != nil || got != id {
+		t.Errorf("Expected peer %s, got %s (%v)", id, got, err)
+	}
+	if _, err := ParseTransport("/ip4/127.0.0.1/tcp/4001").PeerID(); err == nil {
+		t.Error("Expected error for a multiaddr without /p2p")
+	}
+	if _, err := ParseTransport("http://localhost:8080").PeerID(); err == nil {
+		t.Error("Expected error for a custom transport")
+	}
+
+	// The address skips transports without a peer ID
+	address := NewOAddress("o://leader", "http://localhost:8080", withPeer.String())
+	if got, err := address.PeerID(); err != nil || got != id {
+		t.Errorf("Expected peer %s, got %s (%v)", id, got, err)
+	}
+	node := newLoopbackNode("o://agents/planner", NodeTypeAgent, nil)
+	node.config.Parent = address
+	if got, err := node.ParentPeerID(); err != nil || got != id {
+		t.Errorf("Expected parent peer %s, got %s (%v)", id, got, err)
+	}
+	if transports := node.ParentTransports(); len(transports) != 1 || transports[0].String() != withPeer.String() {
+		t.Errorf("Expected the libp2p parent transport, got %v", transports)
+	}
+}
+
+func TestAddressTransports(t *testing.T) {
+	ma, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	if err != nil {
+		t.Fatalf("Failed to create multiaddr: %v", err)
+	}
+	address := NewOAddress("o://leader", ma, "/ip4/127.0.0.1/tcp/4001", "http://localhost:8080", NewInProcessTransport("leader"), "http://localhost:8080", 42)
+
+	if all := address.AllTransports(); len(all) != 3 {
+		t.Errorf("Expected duplicates and invalid values to be dropped, got %v", all)
+	}
+	if libp2p := address.LibP2PTransports(); len(libp2p) != 1 || !libp2p[0].Equal(ma) {
+		t.Errorf("Expected one libp2p transport, got %v", libp2p)
+	}
+	if custom := address.CustomTransports(); len(custom) != 1 || custom[0] != "http://localhost:8080" {
+		t.Errorf("Expected one custom transport, got %v", custom)
+	}
+	if inProcess := address.InProcessTransports(); len(inProcess) != 1 || inProcess[0] != "inproc://leader" {
+		t.Errorf("Expected one in-process transport, got %v", inProcess)
+	}
+
+	// Multiaddr strings are typed as libp2p transports
+	address.SetTransportsFromStrings([]string{"/ip4/10.0.0.1/tcp/4001", "ws://localhost:8080", "/ip4/10.0.0.1/tcp/4001"})
+	if len(address.LibP2PTransports()) != 1 || len(address.CustomTransports()) != 1 {
+		t.Errorf("Expected one libp2p and one custom transport, got %v", address.Transports())
+	}
+
+	address.AddTransports(ParseTransport("ws://localhost:8080"), ParseTransport("ws://localhost:9090"))
+	if all := address.AllTransports(); len(all) != 3 || all[2] != "ws://localhost:9090" {
+		t.Errorf("Expected only the new transport to be added, got %v", all)
+	}
+
+	clone := address.Clone()
+	clone.SetTransports(nil)
+	if len(address.Transports()) != 3 {
+		t.Error("Expected clone to be independent of the original")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/olane-labs/olane-go/pkg/config"
 )
@@ -59,27 +60,45 @@ type CoreConfig struct {
 	Leader        *OAddress
 	Parent        *OAddress
 	Type          NodeType
-	Seed          string
+	Seed          string // derives a stable Ed25519 identity when set
 	Name          string
 	Network       *config.Libp2pConfig
 	Metrics       bool
 	Description   string
+	Tags          []string // advertised in whoami and the leader registry for capability search
 	Dependencies  []*ODependency
 	Methods       map[string]*OMethod
 	CWD           string
 	NetworkName   string
 	PromptAddress *OAddress
+	// Logger overrides the node's default stdout logger
+	Logger Logger
+	// MetricsAddress is the local HTTP address metrics are served on when Metrics is enabled
+	MetricsAddress string
+	// TracerProvider creates the node's OpenTelemetry spans (nil uses the global provider, a no-op by default)
+	TracerProvider trace.TracerProvider
+	// Election enables leader election among several leaders, or leader failover on other nodes (nil uses the single Leader)
+	Election *ElectionConfig
+	// Heartbeat controls registry heartbeats and the leader's health checks (nil uses DefaultHeartbeatConfig)
+	Heartbeat *HeartbeatConfig
+	// Embedder enables semantic registry search on leaders (nil searches by keyword only)
+	Embedder Embedder
+	// ResolutionCache controls caching of static address resolutions (nil uses DefaultResolutionCacheConfig)
+	ResolutionCache *ResolutionCacheConfig
+	// Features are the optional protocol features the node offers peers in its hello
+	Features []Feature
 }
 
 // DefaultCoreConfig returns a default core configuration
 func DefaultCoreConfig() *CoreConfig {
 	return &CoreConfig{
-		Address:      NewOAddress("o://node"),
-		Type:         NodeTypeUnknown,
-		Network:      config.DefaultLibp2pConfig(),
-		Metrics:      false,
-		Dependencies: []*ODependency{},
-		Methods:      make(map[string]*OMethod),
+		Address:        NewOAddress("o://node"),
+		Type:           NodeTypeUnknown,
+		Network:        config.DefaultLibp2pConfig(),
+		Metrics:        false,
+		MetricsAddress: DefaultMetricsAddress,
+		Dependencies:   []*ODependency{},
+		Methods:        make(map[string]*OMethod),
 	}
 }
 
@@ -140,7 +159,7 @@ func NewOErrorResponse(id string, code int, message string, data interface{}) *O
 
 // UseOptions provides options for the Use method
 type UseOptions struct {
-	NoIndex bool
+	NoIndex bool // bypasses the resolution cache, asking the leader to resolve static addresses
 	Timeout int // timeout in seconds
 }
 
@@ -164,23 +183,17 @@ type WhoAmIResponse struct {
 	Type         NodeType            `json:"type"`
 	Description  string              `json:"description"`
 	Methods      map[string]*OMethod `json:"methods"`
+	Tags         []string            `json:"tags,omitempty"`
 	SuccessCount int64               `json:"successCount"`
 	ErrorCount   int64               `json:"errorCount"`
 	PeerID       string              `json:"peerId"`
 	Transports   []string            `json:"transports"`
+	Protocol     *ProtocolInfo       `json:"protocol,omitempty"`
 }
 
-// Logger interface for structured logging
-type Logger interface {
-	Debug(args ...interface{})
-	Info(args ...interface{})
-	Warn(args ...interface{})
-	Error(args ...interface{})
-	Debugf(format string, args ...interface{})
-	Infof(format string, args ...interface{})
-	Warnf(format string, args ...interface{})
-	Errorf(format string, args ...interface{})
-}
+// Logger interface for structured logging.
+// It is shared with the config package so node loggers can be passed to CreateNode.
+type Logger = config.Logger
 
 // Connection interface represents a connection to another node
 type Connection interface {
@@ -188,6 +201,8 @@ type Connection interface {
 	Close() error
 	RemotePeer() peer.ID
 	RemoteAddr() multiaddr.Multiaddr
+	// Handshake returns what was negotiated with the peer on the first connection
+	Handshake() *Handshake
 }
 
 // ConnectionManager interface manages connections to other nodes
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// readFixture reads a file from testdata/wire
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "wire", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+// assertSameJSON fails unless got and want encode the same JSON value
+func assertSameJSON(t *testing.T, name string, got interface{}, want []byte) {
+	t.Helper()
+	encoded, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %v", name, err)
+	}
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(encoded, &gotValue); err != nil {
+		t.Fatalf("Failed to decode %s: %v", name, err)
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatalf("Failed to decode fixture %s: %v", name, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("Expected %s to match the fixture\n got: %s\nwant: %s", name, encoded, want)
+	}
+}
+
+func TestWireFrames(t *testing.T) {
+	data := readFixture(t, "request.json")
+	var req ORequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if req.Method != "add" || req.Params["a"] != 2.0 {
+		t.Errorf("Expected add request, got %+v", req)
+	}
+	if err := checkRequestProtocol(&req); err != nil {
+		t.Errorf("Expected compatible request, got %v", err)
+	}
+	assertSameJSON(t, "request", &req, data)
+	assertSameJSON(t, "new request", NewORequest(req.ID, req.Method, req.Params), data)
+
+	data = readFixture(t, "response.json")
+	var response OResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assertSameJSON(t, "response", &response, data)
+	assertSameJSON(t, "new response", NewOResponse(response.ID, response.Result), data)
+
+	data = readFixture(t, "error_response.json")
+	var errResponse OResponse
+	if err := json.Unmarshal(data, &errResponse); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if errResponse.Error == nil || errResponse.Error.Code != ErrorCodeMethodNotFound {
+		t.Errorf("Expected method not found error, got %+v", errResponse.Error)
+	}
+	notFound := ErrMethodNotFound("subtract")
+	assertSameJSON(t, "new error response", NewOErrorResponse(errResponse.ID, notFound.Code, notFound.Message, notFound.Data), data)
+}
+
+func TestWireProtocolNegotiation(t *testing.T) {
+	node := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+
+	var req ORequest
+	if err := json.Unmarshal(readFixture(t, "request_incompatible.json"), &req); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	response := node.executeRequest(context.Background(), &req)
+	assertSameJSON(t, "incompatible response", response, readFixture(t, "error_incompatible.json"))
+
+	tests := []struct {
+		version    string
+		compatible bool
+	}{
+		{"", true},
+		{ProtocolVersion, true},
+		{"1.4.2", true},
+		{"1", true},
+		{"v1.0.0", true},
+		{"0.9.0", false},
+		{"2.0.0", false},
+		{"one", false},
+		{"1.0.0.0", false},
+	}
+	for _, tt := range tests {
+		err := CheckProtocolVersion(tt.version)
+		if tt.compatible && err != nil {
+			t.Errorf("Expected %q to be compatible, got %v", tt.version, err)
+		}
+		if !tt.compatible && (err == nil || err.Code != ErrorCodeIncompatibleProtocol) {
+			t.Errorf("Expected %q to be incompatible, got %v", tt.version, err)
+		}
+	}
+
+	// Requests from Go nodes carry the version
+	built := buildRequest(context.Background(), &ConnectionSendParams{Address: "o://calc", Payload: map[string]interface{}{"method": "add"}}, nil)
+	if built.Params[ParamProtocolVersion] != ProtocolVersion {
+		t.Errorf("Expected request to carry the protocol version, got %+v", built)
+	}
+}
+
+func TestWireRegistration(t *testing.T) {
+	data := readFixture(t, "registration.json")
+	var params map[string]interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		t.Fatalf("Failed to decode registration: %v", err)
+	}
+
+	// The fixture registration commits to a leader
+	leader := newLoopbackNode("o://leader", NodeTypeLeader, nil)
+	sender, err := peer.Decode(params["peerId"].(string))
+	if err != nil {
+		t.Fatalf("Invalid registration peer: %v", err)
+	}
+	response := leader.handleRegistryRequest(withRemotePeer(context.Background(), sender), NewORequest("1", "commit", params))
+	if response.Error != nil {
+		t.Fatalf("Failed to commit registration: %v", response.Error)
+	}
+	entries := leader.Registry().Search(&RegistryQuery{StaticAddress: "o://calc"})
+	if len(entries) != 1 {
+		t.Fatalf("Expected one registered entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.PeerID != params["peerId"] || entry.Type != NodeTypeTool || len(entry.Protocols) != 2 || len(entry.Tags) != 1 {
+		t.Errorf("Expected registration fields to decode, got %+v", entry)
+	}
+	if method := entry.Methods["add"]; method == nil || method.Parameters["a"] != "number" {
+		t.Errorf("Expected method description to decode, got %+v", entry.Methods)
+	}
+
+	// Registrations a node sends have the fixture's fields and types
+	node := newLoopbackNode("o://leader/tools/calc", NodeTypeTool, nil)
+	encoded, err := json.Marshal(node.registrationParams())
+	if err != nil {
+		t.Fatalf("Failed to encode registration: %v", err)
+	}
+	var goParams map[string]interface{}
+	if err := json.Unmarshal(encoded, &goParams); err != nil {
+		t.Fatalf("Failed to decode registration: %v", err)
+	}
+	if got, want := sortedKeys(goParams), sortedKeys(params); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected registration fields %v, got %v", want, got)
+	}
+	for key, value := range params {
+		if reflect.TypeOf(goParams[key]) != reflect.TypeOf(value) && goParams[key] != nil {
+			t.Errorf("Expected %s to be a %T, got %T", key, value, goParams[key])
+		}
+	}
+}
+
+func TestWireCIDs(t *testing.T) {
+	var fixtures []struct {
+		Address string `json:"address"`
+		CID     string `json:"cid"`
+	}
+	if err := json.Unmarshal(readFixture(t, "cids.json"), &fixtures); err != nil {
+		t.Fatalf("Failed to decode CIDs: %v", err)
+	}
+	for _, f := range fixtures {
+		c, err := NewOAddress(f.Address).ToCID()
+		if err != nil || c.String() != f.CID {
+			t.Errorf("Expected %s to advertise %s, got %s (%v)", f.Address, f.CID, c, err)
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
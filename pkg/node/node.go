@@ -11,6 +11,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	"github.com/multiformats/go-multiaddr"
 
 	"github.com/olane-labs/olane-go/pkg/config"
@@ -22,18 +24,28 @@ type Node struct {
 	DHT        *dht.IpfsDHT
 	PubSub     *pubsub.PubSub
 	Config     *config.Libp2pConfig
+	mdns       mdns.Service
+	bootstrap  *config.BootstrapReport
+	logger     config.Logger
+	topics     *TopicCache
 	ctx        context.Context
 	cancelFunc context.CancelFunc
 	mu         sync.RWMutex
 	isRunning  bool
 }
 
-// NewNode creates a new Olane network node with the given configuration
+// NewNode creates a new Olane network node with the given configuration.
+// Warnings are sent to cfg.Logger; a nil logger discards them.
 func NewNode(ctx context.Context, cfg *config.Libp2pConfig) (*Node, error) {
 	if cfg == nil {
 		cfg = config.DefaultLibp2pConfig()
 	}
 
+	logger := cfg.Logger
+	if logger == nil {
+		logger = config.NoOpLogger()
+	}
+
 	nodeCtx, cancel := context.WithCancel(ctx)
 
 	// Create the libp2p host and services
@@ -48,6 +60,8 @@ func NewNode(ctx context.Context, cfg *config.Libp2pConfig) (*Node, error) {
 		DHT:        kadDHT,
 		PubSub:     gossipSub,
 		Config:     cfg,
+		logger:     logger,
+		topics:     NewTopicCache(nodeCtx, gossipSub, cfg.PeerScore),
 		ctx:        nodeCtx,
 		cancelFunc: cancel,
 		isRunning:  false,
@@ -66,9 +80,33 @@ func (n *Node) Start() error {
 	}
 
 	// Connect to bootstrap peers
-	if err := config.ConnectToBootstrapPeers(n.ctx, n.Host, n.Config.BootstrapPeers); err != nil {
+	report, err := config.BootstrapPeers(n.ctx, n.Host, n.Config.BootstrapPeers, n.Config.Bootstrap)
+	if err != nil {
 		return fmt.Errorf("failed to connect to bootstrap peers: %w", err)
 	}
+	for _, failed := range report.Failed {
+		n.logger.Warnf("Failed to connect to bootstrap peer %s after %d attempts: %v", failed.Address, failed.Attempts, failed.Err)
+	}
+	n.bootstrap = report
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("bootstrap not ready: %w", err)
+	}
+
+	// Start local peer discovery
+	if n.Config.EnableMDNS {
+		service, err := config.StartMDNS(n.ctx, n.Host, n.Config.MDNSServiceName, nil)
+		if err != nil {
+			return err
+		}
+		n.mdns = service
+	}
+
+	// Re-bootstrap in the background when the peer count runs low. It starts
+	// last so a failed Start leaves nothing running.
+	go config.MaintainBootstrap(n.ctx, n.Host, n.Config.BootstrapPeers, n.Config.Bootstrap, func(report *config.BootstrapReport) {
+		n.logger.Infof("Re-bootstrapped: %d peers reached, %d failed", len(report.Succeeded), len(report.Failed))
+		n.setBootstrapReport(report)
+	})
 
 	n.isRunning = true
 	return nil
@@ -84,19 +122,27 @@ func (n *Node) Stop() error {
 	}
 
 	// Close services in reverse order
-	if n.PubSub != nil {
-		// PubSub doesn't have a Close method, it's cleaned up when the host closes
+	if n.mdns != nil {
+		if err := n.mdns.Close(); err != nil {
+			n.logger.Warnf("Error closing mDNS: %v", err)
+		}
+		n.mdns = nil
+	}
+
+	// Stop subscription handlers and leave topics; PubSub itself is cleaned up when the host closes
+	if err := n.topics.Close(); err != nil {
+		n.logger.Warnf("Error leaving topics: %v", err)
 	}
 
 	if n.DHT != nil {
 		if err := n.DHT.Close(); err != nil {
-			fmt.Printf("Warning: error closing DHT: %v\n", err)
+			n.logger.Warnf("Error closing DHT: %v", err)
 		}
 	}
 
 	if n.Host != nil {
 		if err := n.Host.Close(); err != nil {
-			fmt.Printf("Warning: error closing host: %v\n", err)
+			n.logger.Warnf("Error closing host: %v", err)
 		}
 	}
 
@@ -105,6 +151,20 @@ func (n *Node) Stop() error {
 	return nil
 }
 
+// BootstrapReport returns the result of the most recent bootstrap run, or nil before Start
+func (n *Node) BootstrapReport() *config.BootstrapReport {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.bootstrap
+}
+
+// setBootstrapReport records a bootstrap report (thread-safe)
+func (n *Node) setBootstrapReport(report *config.BootstrapReport) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.bootstrap = report
+}
+
 // IsRunning returns whether the node is currently running
 func (n *Node) IsRunning() bool {
 	n.mu.RLock()
@@ -132,6 +192,11 @@ func (n *Node) PeerCount() int {
 	return len(n.Peers())
 }
 
+// ResourceUsage returns the current resource manager usage (system, transient, per-protocol and per-peer)
+func (n *Node) ResourceUsage() (*rcmgr.ResourceManagerStat, error) {
+	return config.ResourceUsage(n.Host)
+}
+
 // ConnectToPeer connects to a specific peer
 func (n *Node) ConnectToPeer(ctx context.Context, peerAddr string) error {
 	ma, err := multiaddr.NewMultiaddr(peerAddr)
@@ -147,30 +212,6 @@ func (n *Node) ConnectToPeer(ctx context.Context, peerAddr string) error {
 	return n.Host.Connect(ctx, *peerInfo)
 }
 
-// Subscribe subscribes to a pubsub topic
-func (n *Node) Subscribe(topic string) (*pubsub.Subscription, error) {
-	if n.PubSub == nil {
-		return nil, fmt.Errorf("pubsub is not enabled on this node")
-	}
-
-	return n.PubSub.Subscribe(topic)
-}
-
-// Publish publishes data to a pubsub topic
-func (n *Node) Publish(ctx context.Context, topic string, data []byte) error {
-	if n.PubSub == nil {
-		return fmt.Errorf("pubsub is not enabled on this node")
-	}
-
-	topicHandle, err := n.PubSub.Join(topic)
-	if err != nil {
-		return fmt.Errorf("failed to join topic %s: %w", topic, err)
-	}
-	defer topicHandle.Close()
-
-	return topicHandle.Publish(ctx, data)
-}
-
 // GetValue retrieves a value from the DHT
 func (n *Node) GetValue(ctx context.Context, key string) ([]byte, error) {
 	if n.DHT == nil {
@@ -0,0 +1,369 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+)
+
+// DropPolicy decides what a subscription does with a message when its handler queue is full
+type DropPolicy string
+
+const (
+	// DropPolicyBlock stops reading from the topic until the handler catches up.
+	// GossipSub then drops messages for the subscription once its own buffer is full.
+	DropPolicyBlock DropPolicy = "block"
+	// DropPolicyDropNewest discards the incoming message
+	DropPolicyDropNewest DropPolicy = "drop-newest"
+	// DropPolicyDropOldest discards the oldest queued message to make room
+	DropPolicyDropOldest DropPolicy = "drop-oldest"
+)
+
+// SubscribeOptions controls buffering for a handler subscription
+type SubscribeOptions struct {
+	// BufferSize is the number of messages queued for the handler
+	BufferSize int
+	// DropPolicy decides what happens when the queue is full
+	DropPolicy DropPolicy
+}
+
+// DefaultSubscribeOptions returns the default subscription options
+func DefaultSubscribeOptions() *SubscribeOptions {
+	return &SubscribeOptions{
+		BufferSize: 32,
+		DropPolicy: DropPolicyBlock,
+	}
+}
+
+// MessageHandler is called for every message received on a subscription
+type MessageHandler func(ctx context.Context, msg *pubsub.Message)
+
+// TopicInfo describes a joined topic
+type TopicInfo struct {
+	Name          string `json:"name"`
+	Peers         int    `json:"peers"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+// TopicSubscription is a subscription whose messages are delivered to a handler
+type TopicSubscription struct {
+	topic   string
+	sub     *pubsub.Subscription
+	cancel  context.CancelFunc
+	done    chan struct{}
+	dropped atomic.Uint64
+	remove  func(*TopicSubscription)
+	once    sync.Once
+}
+
+// Topic returns the topic name of the subscription
+func (s *TopicSubscription) Topic() string {
+	return s.topic
+}
+
+// Dropped returns the number of messages discarded by the drop policy
+func (s *TopicSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Cancel stops the subscription and waits for its handler to return
+func (s *TopicSubscription) Cancel() {
+	s.once.Do(func() {
+		s.cancel()
+		<-s.done
+		s.sub.Cancel()
+		s.remove(s)
+	})
+}
+
+// topicState holds a cached topic handle and its subscriptions
+type topicState struct {
+	handle   *pubsub.Topic
+	handlers map[*TopicSubscription]struct{}
+	raw      []*pubsub.Subscription
+}
+
+// TopicCache holds the GossipSub topics joined on a PubSub and their subscriptions.
+// PubSub allows a topic to be joined only once, so everything publishing or
+// subscribing through one PubSub must share its TopicCache.
+type TopicCache struct {
+	pubsub *pubsub.PubSub
+	score  *config.PeerScoreConfig
+	ctx    context.Context
+	topics map[string]*topicState
+	mu     sync.Mutex
+}
+
+// NewTopicCache creates a topic cache for ps, which may be nil when pubsub is
+// disabled. Handler subscriptions stop when ctx is done; score is applied to
+// joined topics and may be nil.
+func NewTopicCache(ctx context.Context, ps *pubsub.PubSub, score *config.PeerScoreConfig) *TopicCache {
+	return &TopicCache{
+		pubsub: ps,
+		score:  score,
+		ctx:    ctx,
+		topics: make(map[string]*topicState),
+	}
+}
+
+// join returns the cached state for a topic, joining it on first use; callers hold mu
+func (c *TopicCache) join(name string) (*topicState, error) {
+	if c.pubsub == nil {
+		return nil, fmt.Errorf("pubsub is not enabled on this node")
+	}
+	if state, ok := c.topics[name]; ok {
+		return state, nil
+	}
+
+	handle, err := config.JoinTopic(c.pubsub, name, c.score)
+	if err != nil {
+		return nil, err
+	}
+	state := &topicState{handle: handle, handlers: make(map[*TopicSubscription]struct{})}
+	c.topics[name] = state
+	return state, nil
+}
+
+// Join returns the shared handle for a topic, joining it on first use
+func (c *TopicCache) Join(name string) (*pubsub.Topic, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, err := c.join(name)
+	if err != nil {
+		return nil, err
+	}
+	return state.handle, nil
+}
+
+// Subscribe subscribes to a topic. The subscription is cancelled when the topic
+// is unsubscribed or the cache is closed.
+func (c *TopicCache) Subscribe(topic string) (*pubsub.Subscription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, err := c.join(topic)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := state.handle.Subscribe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	state.raw = append(state.raw, sub)
+	return sub, nil
+}
+
+// SubscribeFunc subscribes to a topic and calls handler for every message on the
+// subscription's own goroutine, so a slow handler only delays its own topic.
+// Handlers stop when the subscription is cancelled, the topic is unsubscribed or
+// the cache's context is done. A nil opts uses DefaultSubscribeOptions.
+func (c *TopicCache) SubscribeFunc(topic string, handler MessageHandler, opts *SubscribeOptions) (*TopicSubscription, error) {
+	if opts == nil {
+		opts = DefaultSubscribeOptions()
+	}
+	if opts.BufferSize <= 0 {
+		return nil, fmt.Errorf("buffer size must be positive")
+	}
+	switch opts.DropPolicy {
+	case DropPolicyBlock, DropPolicyDropNewest, DropPolicyDropOldest:
+	default:
+		return nil, fmt.Errorf("unknown drop policy %q", opts.DropPolicy)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, err := c.join(topic)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := state.handle.Subscribe(pubsub.WithBufferSize(opts.BufferSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	s := &TopicSubscription{
+		topic:  topic,
+		sub:    sub,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		remove: c.removeSubscription,
+	}
+	state.handlers[s] = struct{}{}
+
+	queue := make(chan *pubsub.Message, opts.BufferSize)
+	go s.read(ctx, queue, opts.DropPolicy)
+	go func() {
+		defer close(s.done)
+		for msg := range queue {
+			if ctx.Err() != nil {
+				continue
+			}
+			handler(ctx, msg)
+		}
+	}()
+
+	return s, nil
+}
+
+// read moves messages from the subscription to the handler queue, applying the drop policy
+func (s *TopicSubscription) read(ctx context.Context, queue chan *pubsub.Message, policy DropPolicy) {
+	defer close(queue)
+	for {
+		msg, err := s.sub.Next(ctx)
+		if err != nil {
+			return
+		}
+
+		switch policy {
+		case DropPolicyBlock:
+			select {
+			case queue <- msg:
+			case <-ctx.Done():
+				return
+			}
+		case DropPolicyDropNewest:
+			select {
+			case queue <- msg:
+			default:
+				s.dropped.Add(1)
+			}
+		case DropPolicyDropOldest:
+			s.enqueueDropOldest(queue, msg)
+		}
+	}
+}
+
+// enqueueDropOldest queues msg, discarding the oldest queued messages until it fits
+func (s *TopicSubscription) enqueueDropOldest(queue chan *pubsub.Message, msg *pubsub.Message) {
+	for {
+		select {
+		case queue <- msg:
+			return
+		default:
+		}
+
+		select {
+		case <-queue:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// removeSubscription forgets a cancelled handler subscription
+func (c *TopicCache) removeSubscription(s *TopicSubscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state, ok := c.topics[s.topic]; ok {
+		delete(state.handlers, s)
+	}
+}
+
+// Unsubscribe cancels every subscription to a topic and leaves it
+func (c *TopicCache) Unsubscribe(topic string) error {
+	c.mu.Lock()
+	state, ok := c.topics[topic]
+	delete(c.topics, topic)
+	c.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("not subscribed to topic %s", topic)
+	}
+	return closeTopic(state)
+}
+
+// closeTopic cancels a topic's subscriptions and releases its handle.
+// PubSub refuses to close a topic that still has subscriptions, so they go first.
+func closeTopic(state *topicState) error {
+	for s := range state.handlers {
+		s.Cancel()
+	}
+	for _, sub := range state.raw {
+		sub.Cancel()
+	}
+	if err := state.handle.Close(); err != nil {
+		return fmt.Errorf("failed to close topic %s: %w", state.handle.String(), err)
+	}
+	return nil
+}
+
+// Close cancels every subscription and leaves every joined topic
+func (c *TopicCache) Close() error {
+	c.mu.Lock()
+	topics := c.topics
+	c.topics = make(map[string]*topicState)
+	c.mu.Unlock()
+
+	var errs []error
+	for _, state := range topics {
+		if err := closeTopic(state); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Topics returns the joined topics with their subscribed peer and local subscription counts
+func (c *TopicCache) Topics() []TopicInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	topics := make([]TopicInfo, 0, len(c.topics))
+	for name, state := range c.topics {
+		topics = append(topics, TopicInfo{
+			Name:          name,
+			Peers:         len(state.handle.ListPeers()),
+			Subscriptions: len(state.handlers) + len(state.raw),
+		})
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics
+}
+
+// Publish publishes data to a topic, joining it on first use
+func (c *TopicCache) Publish(ctx context.Context, topic string, data []byte) error {
+	handle, err := c.Join(topic)
+	if err != nil {
+		return err
+	}
+	return handle.Publish(ctx, data)
+}
+
+// Subscribe subscribes to a pubsub topic
+func (n *Node) Subscribe(topic string) (*pubsub.Subscription, error) {
+	return n.topics.Subscribe(topic)
+}
+
+// SubscribeFunc subscribes to a topic with a handler; see TopicCache.SubscribeFunc
+func (n *Node) SubscribeFunc(topic string, handler MessageHandler, opts *SubscribeOptions) (*TopicSubscription, error) {
+	return n.topics.SubscribeFunc(topic, handler, opts)
+}
+
+// Unsubscribe cancels every subscription to a topic and leaves it
+func (n *Node) Unsubscribe(topic string) error {
+	return n.topics.Unsubscribe(topic)
+}
+
+// Topics returns the joined topics with their subscribed peer and local subscription counts
+func (n *Node) Topics() []TopicInfo {
+	return n.topics.Topics()
+}
+
+// TopicCache returns the node's topic cache, to share with other users of its PubSub
+func (n *Node) TopicCache() *TopicCache {
+	return n.topics
+}
+
+// Publish publishes data to a pubsub topic
+func (n *Node) Publish(ctx context.Context, topic string, data []byte) error {
+	return n.topics.Publish(ctx, topic, data)
+}
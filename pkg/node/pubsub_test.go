@@ -0,0 +1,173 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/olane-labs/olane-go/pkg/config"
+)
+
+// newTestNode starts a node listening on loopback without DHT or relay
+func newTestNode(t *testing.T, ctx context.Context) *Node {
+	cfg := config.DefaultLibp2pConfig()
+	cfg.Listeners = []string{"/ip4/127.0.0.1/tcp/0"}
+	cfg.EnableDHT = false
+	cfg.EnableRelay = false
+
+	n, err := NewNode(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	return n
+}
+
+func TestSubscribeFuncAndTopics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	publisher := newTestNode(t, ctx)
+	defer publisher.Stop()
+	subscriber := newTestNode(t, ctx)
+	defer subscriber.Stop()
+
+	if err := subscriber.Host.Connect(ctx, peer.AddrInfo{ID: publisher.ID(), Addrs: publisher.Addrs()}); err != nil {
+		t.Fatalf("Failed to connect nodes: %v", err)
+	}
+
+	received := make(chan []byte, 10)
+	sub, err := subscriber.SubscribeFunc("test-topic", func(ctx context.Context, msg *pubsub.Message) {
+		received <- msg.Data
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// Publishing repeatedly must reuse the cached topic handle
+	var data []byte
+	for data == nil {
+		if err := publisher.Publish(ctx, "test-topic", []byte("hello")); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		select {
+		case data = <-received:
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+	if string(data) != "hello" {
+		t.Errorf("Expected hello, got %s", data)
+	}
+
+	topics := subscriber.Topics()
+	if len(topics) != 1 || topics[0].Name != "test-topic" {
+		t.Fatalf("Expected one joined topic, got %v", topics)
+	}
+	if topics[0].Subscriptions != 1 {
+		t.Errorf("Expected 1 subscription, got %+v", topics[0])
+	}
+	// Peers counts remote subscribers, so only the publisher sees one
+	if topics := publisher.Topics(); len(topics) != 1 || topics[0].Peers != 1 || topics[0].Subscriptions != 0 {
+		t.Errorf("Expected publisher to see 1 peer and no subscriptions, got %v", topics)
+	}
+
+	sub.Cancel()
+	if topics := subscriber.Topics(); topics[0].Subscriptions != 0 {
+		t.Errorf("Expected no subscriptions after cancel, got %d", topics[0].Subscriptions)
+	}
+
+	if err := subscriber.Unsubscribe("test-topic"); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if topics := subscriber.Topics(); len(topics) != 0 {
+		t.Errorf("Expected no topics after unsubscribe, got %v", topics)
+	}
+	if err := subscriber.Unsubscribe("test-topic"); err == nil {
+		t.Error("Expected error unsubscribing from an unknown topic")
+	}
+}
+
+func TestSubscribeFuncDropPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	n := newTestNode(t, ctx)
+	defer n.Stop()
+
+	release := make(chan struct{})
+	var handled atomic.Int32
+	sub, err := n.SubscribeFunc("busy-topic", func(ctx context.Context, msg *pubsub.Message) {
+		<-release
+		handled.Add(1)
+	}, &SubscribeOptions{BufferSize: 1, DropPolicy: DropPolicyDropNewest})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// The handler holds one message and the queue one more; the rest are dropped
+	for i := 0; i < 10; i++ {
+		if err := n.Publish(ctx, "busy-topic", []byte{byte(i)}); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sub.Dropped() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sub.Dropped() == 0 {
+		t.Error("Expected messages to be dropped")
+	}
+
+	close(release)
+	sub.Cancel()
+	if handled.Load() > 2 {
+		t.Errorf("Expected at most 2 handled messages, got %d", handled.Load())
+	}
+}
+
+func TestStopEndsHandlers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	n := newTestNode(t, ctx)
+	sub, err := n.SubscribeFunc("stop-topic", func(ctx context.Context, msg *pubsub.Message) {}, nil)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := n.Stop(); err != nil {
+		t.Fatalf("Failed to stop: %v", err)
+	}
+
+	select {
+	case <-sub.done:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected handler goroutine to stop with the node")
+	}
+}
+
+func TestSubscribeFuncRejectsBadOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	n := newTestNode(t, ctx)
+	defer n.Stop()
+
+	handler := func(ctx context.Context, msg *pubsub.Message) {}
+	if _, err := n.SubscribeFunc("t", handler, &SubscribeOptions{BufferSize: 0, DropPolicy: DropPolicyBlock}); err == nil {
+		t.Error("Expected error for zero buffer size")
+	}
+	if _, err := n.SubscribeFunc("t", handler, &SubscribeOptions{BufferSize: 1, DropPolicy: "sometimes"}); err == nil {
+		t.Error("Expected error for unknown drop policy")
+	}
+}
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// KeyFormat is a private key serialization format
+type KeyFormat string
+
+const (
+	// KeyFormatProtobuf is the raw libp2p protobuf encoding
+	KeyFormatProtobuf KeyFormat = "protobuf"
+	// KeyFormatBase64 is the base64 encoded libp2p protobuf, as used by PrivKeyToBase64
+	KeyFormatBase64 KeyFormat = "base64"
+	// KeyFormatPEM is PKCS#8 PEM, or a LIBP2P PRIVATE KEY block for key types PKCS#8 cannot hold
+	KeyFormatPEM KeyFormat = "pem"
+)
+
+// PEM block types written by EncodePrivKey
+const (
+	pemTypePKCS8  = "PRIVATE KEY"
+	pemTypeLibp2p = "LIBP2P PRIVATE KEY"
+)
+
+// DefaultRSABits is the RSA key size used by GenerateKeyPairOfType
+const DefaultRSABits = 2048
+
+// ParseKeyType converts a key type name (ed25519, rsa, secp256k1, ecdsa) to a libp2p key type
+func ParseKeyType(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "ed25519":
+		return crypto.Ed25519, nil
+	case "rsa":
+		return crypto.RSA, nil
+	case "secp256k1":
+		return crypto.Secp256k1, nil
+	case "ecdsa":
+		return crypto.ECDSA, nil
+	default:
+		return 0, fmt.Errorf("unsupported key type %q", name)
+	}
+}
+
+// KeyTypeName returns the lowercase name of a libp2p key type
+func KeyTypeName(keyType int) string {
+	return strings.ToLower(pb.KeyType(keyType).String())
+}
+
+// GenerateKeyPairOfType generates a key pair of the given libp2p key type
+func GenerateKeyPairOfType(keyType int) (crypto.PrivKey, crypto.PubKey, error) {
+	bits := -1
+	if keyType == crypto.RSA {
+		bits = DefaultRSABits
+	}
+	return crypto.GenerateKeyPairWithReader(keyType, bits, rand.Reader)
+}
+
+// DeriveEd25519Key deterministically derives an Ed25519 key from a seed string.
+// The SHA-256 digest of the seed is used as the Ed25519 private key seed, so the
+// same seed always yields the same peer ID.
+func DeriveEd25519Key(seed string) (crypto.PrivKey, error) {
+	if seed == "" {
+		return nil, fmt.Errorf("seed must not be empty")
+	}
+	digest := sha256.Sum256([]byte(seed))
+	priv, _, err := crypto.GenerateEd25519Key(bytes.NewReader(digest[:]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return priv, nil
+}
+
+// EncodePrivKey serializes a private key in the given format
+func EncodePrivKey(priv crypto.PrivKey, format KeyFormat) ([]byte, error) {
+	switch format {
+	case KeyFormatProtobuf:
+		return crypto.MarshalPrivateKey(priv)
+	case KeyFormatBase64:
+		b64, err := PrivKeyToBase64(priv)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(b64 + "\n"), nil
+	case KeyFormatPEM:
+		return encodePrivKeyPEM(priv)
+	default:
+		return nil, fmt.Errorf("unsupported key format %q", format)
+	}
+}
+
+// encodePrivKeyPEM writes PKCS#8 when the standard library supports the key
+// type and falls back to a PEM-wrapped libp2p protobuf otherwise
+func encodePrivKeyPEM(priv crypto.PrivKey) ([]byte, error) {
+	if std, err := crypto.PrivKeyToStdKey(priv); err == nil {
+		// libp2p returns Ed25519 keys by pointer, which x509 does not accept
+		if key, ok := std.(*ed25519.PrivateKey); ok {
+			std = *key
+		}
+		if der, err := x509.MarshalPKCS8PrivateKey(std); err == nil {
+			return pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS8, Bytes: der}), nil
+		}
+	}
+
+	data, err := crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeLibp2p, Bytes: data}), nil
+}
+
+// DecodePrivKey parses a private key in any format written by EncodePrivKey,
+// detecting the format from the data
+func DecodePrivKey(data []byte) (crypto.PrivKey, KeyFormat, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("-----BEGIN")) {
+		priv, err := decodePrivKeyPEM(trimmed)
+		return priv, KeyFormatPEM, err
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(string(trimmed)); err == nil {
+		if priv, err := crypto.UnmarshalPrivateKey(decoded); err == nil {
+			return priv, KeyFormatBase64, nil
+		}
+	}
+
+	priv, err := crypto.UnmarshalPrivateKey(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("unrecognized private key encoding: %w", err)
+	}
+	return priv, KeyFormatProtobuf, nil
+}
+
+// decodePrivKeyPEM parses a PKCS#8 or LIBP2P PRIVATE KEY block
+func decodePrivKeyPEM(data []byte) (crypto.PrivKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+
+	switch block.Type {
+	case pemTypeLibp2p:
+		return crypto.UnmarshalPrivateKey(block.Bytes)
+	case pemTypePKCS8:
+		std, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PKCS#8 key: %w", err)
+		}
+		if key, ok := std.(ed25519.PrivateKey); ok {
+			std = &key
+		}
+		priv, _, err := crypto.KeyPairFromStdKey(std)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert PKCS#8 key: %w", err)
+		}
+		return priv, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// ReadPrivKeyFile reads a private key file in any supported format
+func ReadPrivKeyFile(path string) (crypto.PrivKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	priv, _, err := DecodePrivKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key file %s: %w", path, err)
+	}
+	return priv, nil
+}
+
+// KeyInfo describes a private key and the identity it produces
+type KeyInfo struct {
+	Type      string `json:"type"`
+	PeerID    string `json:"peerId"`
+	PublicKey string `json:"publicKey"`
+	Bits      int    `json:"bits,omitempty"`
+}
+
+// InspectPrivKey returns the key type, peer ID and base64 protobuf public key of a private key
+func InspectPrivKey(priv crypto.PrivKey) (*KeyInfo, error) {
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive peer ID: %w", err)
+	}
+	pub, err := crypto.MarshalPublicKey(priv.GetPublic())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	info := &KeyInfo{
+		Type:      KeyTypeName(int(priv.Type())),
+		PeerID:    id.String(),
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+	}
+	if std, err := crypto.PubKeyToStdKey(priv.GetPublic()); err == nil {
+		if rsaKey, ok := std.(*rsa.PublicKey); ok {
+			info.Bits = rsaKey.Size() * 8
+		}
+	}
+	return info, nil
+}
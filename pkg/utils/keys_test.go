@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+func TestEncodeDecodePrivKey(t *testing.T) {
+	formats := []KeyFormat{KeyFormatProtobuf, KeyFormatBase64, KeyFormatPEM}
+
+	for _, name := range []string{"ed25519", "rsa", "secp256k1", "ecdsa"} {
+		keyType, err := ParseKeyType(name)
+		if err != nil {
+			t.Fatalf("Failed to parse key type %s: %v", name, err)
+		}
+		priv, _, err := GenerateKeyPairOfType(keyType)
+		if err != nil {
+			t.Fatalf("Failed to generate %s key: %v", name, err)
+		}
+
+		for _, format := range formats {
+			data, err := EncodePrivKey(priv, format)
+			if err != nil {
+				t.Fatalf("Failed to encode %s key as %s: %v", name, format, err)
+			}
+
+			decoded, detected, err := DecodePrivKey(data)
+			if err != nil {
+				t.Fatalf("Failed to decode %s key from %s: %v", name, format, err)
+			}
+			if detected != format {
+				t.Errorf("Expected %s key format %s to be detected, got %s", name, format, detected)
+			}
+			if !decoded.Equals(priv) {
+				t.Errorf("Expected %s key to survive %s round trip", name, format)
+			}
+		}
+	}
+}
+
+func TestDeriveEd25519Key(t *testing.T) {
+	first, err := DeriveEd25519Key("olane")
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	second, err := DeriveEd25519Key("olane")
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	other, err := DeriveEd25519Key("other")
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+
+	if !first.Equals(second) {
+		t.Error("Expected the same seed to derive the same key")
+	}
+	if first.Equals(other) {
+		t.Error("Expected different seeds to derive different keys")
+	}
+	if first.Type() != crypto.Ed25519 {
+		t.Errorf("Expected Ed25519 key, got %s", KeyTypeName(int(first.Type())))
+	}
+
+	if _, err := DeriveEd25519Key(""); err == nil {
+		t.Error("Expected error for empty seed")
+	}
+}
+
+func TestInspectPrivKey(t *testing.T) {
+	priv, _, err := GenerateKeyPairOfType(crypto.RSA)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	info, err := InspectPrivKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to inspect key: %v", err)
+	}
+
+	id, _ := PeerIDFromPrivKey(priv)
+	if info.PeerID != id.String() {
+		t.Errorf("Expected peer ID %s, got %s", id, info.PeerID)
+	}
+	if info.Type != "rsa" {
+		t.Errorf("Expected type rsa, got %s", info.Type)
+	}
+	if info.Bits != DefaultRSABits {
+		t.Errorf("Expected %d bits, got %d", DefaultRSABits, info.Bits)
+	}
+}